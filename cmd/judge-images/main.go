@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend/internal/config"
+	"backend/internal/languages"
+	"backend/internal/services"
+)
+
+// Управление судейскими образами:
+//
+//	judge-images list            - образы языков, есть ли они и их digest
+//	judge-images build [image]   - собрать образы из каталога images
+//	judge-images pull [image]    - скачать образы, которые не собираются здесь
+//	judge-images verify [image]  - проверить, что в образах есть тулчейны языков
+//
+// Без аргументов команда применяется ко всем образам реестра языков
+
+// manifestImage образ, который собирается из images/<context>/Dockerfile
+type manifestImage struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+}
+
+func main() {
+	dir := flag.String("dir", "images", "каталог с Dockerfile судейских образов и images.json")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-dir images] list|build|pull|verify [image...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	registry, err := languages.Load(cfg.LanguagesFile)
+	if err != nil {
+		log.Fatalf("❌ Failed to load languages: %v", err)
+	}
+	manifest, err := loadManifest(filepath.Join(*dir, "images.json"))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	docker, err := services.NewDockerService(registry)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	docker.SetTmpSize(cfg.Docker.TmpSizeMB)
+	if err := docker.SetSecurity(cfg.Docker.Security); err != nil {
+		log.Fatalf("❌ Docker security profile: %v", err)
+	}
+
+	images := flag.Args()[1:]
+	explicit := len(images) > 0
+	if !explicit {
+		images = registry.Images()
+	}
+
+	ctx := context.Background()
+	failed := false
+	switch flag.Arg(0) {
+	case "list":
+		for _, image := range images {
+			digest, err := docker.ImageDigest(ctx, image)
+			if err != nil {
+				digest = "missing"
+			}
+			source := "registry"
+			if _, ok := manifest[image]; ok {
+				source = "images/" + manifest[image].Context
+			}
+			fmt.Printf("%-32s %-18s %s\n", image, source, digest)
+		}
+	case "build":
+		for _, image := range images {
+			entry, ok := manifest[image]
+			if !ok {
+				log.Printf("⏭️ %s is not built here, use pull", image)
+				continue
+			}
+			log.Printf("🔨 Building %s", image)
+			if err := docker.BuildImage(ctx, filepath.Join(*dir, entry.Context), image, os.Stdout); err != nil {
+				log.Printf("❌ %v", err)
+				failed = true
+				continue
+			}
+			printDigest(ctx, docker, image)
+		}
+	case "pull":
+		for _, image := range images {
+			// Свои образы по умолчанию собираются, а не скачиваются
+			if _, ok := manifest[image]; ok && !explicit {
+				continue
+			}
+			log.Printf("⬇️ Pulling %s", image)
+			if err := docker.PullImage(ctx, image, os.Stdout); err != nil {
+				log.Printf("❌ %v", err)
+				failed = true
+				continue
+			}
+			printDigest(ctx, docker, image)
+		}
+	case "verify":
+		failed = !verify(ctx, docker, registry, images)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func loadManifest(path string) (map[string]manifestImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image manifest: %w", err)
+	}
+	var manifest struct {
+		Images []manifestImage `json:"images"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid image manifest %s: %w", path, err)
+	}
+
+	byName := make(map[string]manifestImage, len(manifest.Images))
+	for _, image := range manifest.Images {
+		if image.Name == "" || image.Context == "" {
+			return nil, fmt.Errorf("invalid image manifest %s: name and context are required", path)
+		}
+		byName[image.Name] = image
+	}
+	return byName, nil
+}
+
+func printDigest(ctx context.Context, docker *services.DockerService, image string) {
+	digest, err := docker.ImageDigest(ctx, image)
+	if err != nil {
+		log.Printf("❌ %s: %v", image, err)
+		return
+	}
+	log.Printf("✅ %s %s", image, digest)
+}
+
+// verify проверяет, что образ есть и в нем находятся программы сборки и
+// запуска каждого языка (и его юнит-тестов), и печатает версии тулчейнов
+func verify(ctx context.Context, docker *services.DockerService, registry *languages.Registry, images []string) bool {
+	wanted := make(map[string]bool)
+	for _, image := range images {
+		wanted[image] = true
+	}
+
+	type target struct {
+		name string
+		lang *languages.Language
+	}
+	var targets []target
+	for _, lang := range registry.All() {
+		targets = append(targets, target{lang.ID, lang})
+		if variant, hasUnit := lang.UnitVariant(); hasUnit {
+			targets = append(targets, target{lang.ID + " " + lang.Unit.Framework, variant})
+		}
+	}
+
+	ok := true
+	for _, t := range targets {
+		image := t.lang.Docker.Image
+		if image == "" || !wanted[image] {
+			continue
+		}
+		if _, err := docker.ImageDigest(ctx, image); err != nil {
+			log.Printf("❌ %s: image %s is missing", t.name, image)
+			ok = false
+			continue
+		}
+
+		if tools := t.lang.Tools(); len(tools) > 0 {
+			script := "for tool in " + strings.Join(tools, " ") + `; do command -v "$tool" >/dev/null || { echo "$tool"; exit 1; }; done`
+			if output, err := docker.RunImage(ctx, image, []string{"/bin/sh", "-c", script}); err != nil {
+				log.Printf("❌ %s: %s lacks %s", t.name, image, strings.TrimSpace(output))
+				ok = false
+				continue
+			}
+		}
+
+		version := ""
+		if len(t.lang.VersionCmd) > 0 {
+			output, err := docker.RunImage(ctx, image, t.lang.VersionCmd)
+			if err != nil {
+				log.Printf("❌ %s: %v", t.name, err)
+				ok = false
+				continue
+			}
+			version = firstLine(output)
+		}
+		log.Printf("✅ %s: %s %s", t.name, image, version)
+	}
+	return ok
+}
+
+func firstLine(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"backend/internal/config"
+	"backend/internal/judge"
+	"backend/internal/languages"
+)
+
+// Воркер проверки: регистрируется в API (JUDGE_API_URL, JUDGE_TOKEN),
+// берет задания из очереди и выполняет их в Docker или в песочнице
+// локального исполнителя, как это делал бы сам API.
+// API должен быть запущен с JUDGE_MODE=remote
+
+func main() {
+	cfg := config.Load()
+	if cfg.Judge.Token == "" {
+		log.Fatalf("❌ JUDGE_TOKEN is required")
+	}
+
+	registry, err := languages.Load(cfg.LanguagesFile)
+	if err != nil {
+		log.Fatalf("❌ Failed to load languages: %v", err)
+	}
+	runner, err := judge.NewRunner(cfg, registry)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if runner.Docker != nil {
+		runner.Docker.EnsureImages(ctx, registry.Images(), cfg.Docker.PullMissing)
+	}
+
+	// Язык, образа которого нет, в Docker не отправляем
+	var images languages.ImageChecker
+	if runner.Docker != nil {
+		images = runner.Docker
+	}
+	toolchains := languages.NewProber(registry, images, runner)
+	toolchains.Probe(ctx)
+	go toolchains.Run(ctx, cfg.ToolchainProbeInterval)
+	runner.SetToolchains(toolchains)
+
+	info := judge.WorkerInfo{
+		Name:      cfg.Judge.WorkerName,
+		Slots:     cfg.Judge.Slots,
+		Languages: cfg.Judge.Languages,
+		Docker:    runner.Docker != nil,
+	}
+	languagesInfo := "all languages"
+	if len(info.Languages) > 0 {
+		languagesInfo = strings.Join(info.Languages, ", ")
+	}
+	log.Printf("🚀 Judge worker %s: %s, %d slots, %s", info.Name, cfg.Judge.APIURL, info.Slots, languagesInfo)
+
+	transport := judge.NewHTTPTransport(cfg.Judge.APIURL, cfg.Judge.Token, cfg.Judge.LeaseWait)
+	judge.NewWorker(transport, info, cfg.Judge.HeartbeatInterval, runner.Run).Run(ctx)
+	log.Println("👋 Judge worker stopped")
+}
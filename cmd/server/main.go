@@ -85,9 +85,21 @@ func main() {
 	http.HandleFunc("/api/tasks", loggingMiddleware(corsMiddleware(handlers.TasksHandler)))
 	http.HandleFunc("/api/check", loggingMiddleware(corsMiddleware(handlers.CheckHandler)))
 	http.HandleFunc("/api/execute", loggingMiddleware(corsMiddleware(handlers.ExecuteHandler)))
+	http.HandleFunc("/api/execute/stream", loggingMiddleware(corsMiddleware(handlers.ExecuteStreamHandler)))
+	http.HandleFunc("/api/session", loggingMiddleware(handlers.NewSessionHandler(getAllowedOrigins()).ServeHTTP))
+	http.HandleFunc("/api/languages", loggingMiddleware(corsMiddleware(handlers.LanguagesHandler)))
+	// Очередь для воркеров проверки: без CORS и без логов, воркеры опрашивают ее постоянно
+	http.HandleFunc("/api/judge/", handlers.JudgeHandler)
 	http.HandleFunc("/api/auth/login", loggingMiddleware(corsMiddleware(handlers.LoginHandler)))
 	http.HandleFunc("/api/auth/guest", loggingMiddleware(corsMiddleware(handlers.GuestAuthHandler)))
 	http.HandleFunc("/api/auth/register", loggingMiddleware(corsMiddleware(handlers.RegisterHandler)))
+	http.HandleFunc("/api/me/progress", loggingMiddleware(corsMiddleware(handlers.MeProgressHandler)))
+	http.HandleFunc("/api/me/submissions", loggingMiddleware(corsMiddleware(handlers.MySubmissionsHandler)))
+	http.HandleFunc("/api/submissions/", loggingMiddleware(corsMiddleware(handlers.SubmissionHandler)))
+	http.HandleFunc("/api/me/drafts/", loggingMiddleware(corsMiddleware(handlers.DraftHandler)))
+	http.HandleFunc("/api/me/leaderboard", loggingMiddleware(corsMiddleware(handlers.MeLeaderboardHandler)))
+	http.HandleFunc("/api/leaderboard", loggingMiddleware(corsMiddleware(handlers.LeaderboardHandler)))
+	http.HandleFunc("/api/leaderboard/around-me", loggingMiddleware(corsMiddleware(handlers.LeaderboardAroundMeHandler)))
 
 	// Test endpoint
 	http.HandleFunc("/api/test", loggingMiddleware(corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -112,7 +124,9 @@ func main() {
 			"api":         "ok",
 			"environment": getEnvironment(),
 			"timestamp":   time.Now().Format(time.RFC3339),
-			"compilers":   []string{"python", "node", "g++", "javac"},
+			"compilers":   availableCompilers(),
+			"languages":   handlers.Toolchains(),
+			"executor":    handlers.ExecutorStatus(),
 		}
 
 		response := map[string]interface{}{
@@ -135,7 +149,9 @@ func main() {
 			"port":         port,
 			"version":      "1.0.0",
 			"frontend_url": getFrontendURL(),
-			"compilers":    []string{"python", "node", "g++", "javac"},
+			"compilers":    availableCompilers(),
+			"languages":    handlers.Toolchains(),
+			"executor":     handlers.ExecutorStatus(),
 		}
 		json.NewEncoder(w).Encode(response)
 	})))
@@ -157,17 +173,15 @@ func main() {
 		taskId := parts[2]
 
 		// Валидация языка
-		supportedLanguages := map[string]bool{
-			"python":     true,
-			"javascript": true,
-			"cpp":        true,
-			"java":       true,
-		}
-
-		if !supportedLanguages[lang] {
-			http.Error(w, `{"error": "Unsupported language. Use: python, javascript, cpp, java"}`, http.StatusBadRequest)
+		language, ok := handlers.Languages().Lookup(lang)
+		if !ok {
+			message := "Unsupported language. Use: " + strings.Join(handlers.Languages().IDs(), ", ")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": message})
 			return
 		}
+		lang = language.ID
+		limits, _ := handlers.TaskLimits(taskId, lang)
 
 		task := map[string]interface{}{
 			"id":          taskId,
@@ -176,12 +190,19 @@ func main() {
 			"language":    lang,
 			"topic":       topic,
 			"difficulty":  "beginner",
-			"defaultCode": getDefaultCode(lang),
+			"defaultCode": handlers.DefaultCode(taskId, language),
+			"limits":      limits,
 			"supported":   true,
 			"environment": getEnvironment(),
 			"backend_url": getBackendURL(),
 		}
 
+		// Сохраненный черновик ученика открывается вместо шаблона
+		if draft := handlers.TaskDraft(r, taskId, lang); draft != nil {
+			task["defaultCode"] = draft.Code
+			task["draft"] = draft
+		}
+
 		json.NewEncoder(w).Encode(task)
 	})))
 
@@ -201,7 +222,7 @@ func main() {
 			"error":         "API endpoint not found",
 			"path":          r.URL.Path,
 			"timestamp":     time.Now().Format(time.RFC3339),
-			"documentation": "Available endpoints: /api/execute, /api/check, /api/task/:lang/:topic/:id",
+			"documentation": "Available endpoints: /api/execute, /api/execute/stream, /api/session, /api/check, /api/languages, /api/task/:lang/:topic/:id",
 			"backend_url":   getBackendURL(),
 		})
 	})))
@@ -213,14 +234,18 @@ func main() {
 	log.Printf("   GET  /health")
 	log.Printf("   GET  /api/health")
 	log.Printf("   POST /api/execute")
+	log.Printf("   POST /api/execute/stream (SSE)")
+	log.Printf("   GET  /api/session (WebSocket)")
 	log.Printf("   POST /api/check")
+	log.Printf("   GET  /api/languages")
 	log.Printf("   GET  /api/task/:lang/:topic/:id")
 
 	// Запускаем сервер
 	server := &http.Server{
-		Addr:         ":" + port,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		Addr:        ":" + port,
+		ReadTimeout: 15 * time.Second,
+		// Ответ на /api/execute ждет компиляцию (до 60с) и запуск (до 30с)
+		WriteTimeout: 2 * time.Minute,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -291,29 +316,13 @@ func getBackendURL() string {
 	return "http://localhost:8080"
 }
 
-func getDefaultCode(lang string) string {
-	switch lang {
-	case "python":
-		return "# Write your Python code here\nprint(\"Hello World\")"
-	case "javascript":
-		return "// Write your JavaScript code here\nconsole.log(\"Hello World\")"
-	case "cpp":
-		return `// Write your C++ code here
-#include <iostream>
-using namespace std;
-
-int main() {
-    std::cout << "Hello World" << std::endl;
-    return 0;
-}`
-	case "java":
-		return `// Write your Java code here
-public class Main {
-    public static void main(String[] args) {
-        System.out.println("Hello World");
-    }
-}`
-	default:
-		return "// Write your code here"
+// availableCompilers - языки, которые сейчас реально можно запустить
+func availableCompilers() []string {
+	available := []string{}
+	for _, status := range handlers.Toolchains() {
+		if status.Available {
+			available = append(available, status.ID)
+		}
 	}
+	return available
 }
@@ -0,0 +1,192 @@
+package checker
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Проверка вывода решения. Встроенные режимы сравнивают ожидаемый и
+// фактический вывод сами, custom запускает программу-чекер автора задачи.
+//
+// Чекер запускается как обычное решение (в песочнице или контейнере) и
+// находит рядом с собой файлы input.txt, expected.txt и actual.txt.
+// Первая строка его вывода - OK или WA, остальное - комментарий для ученика.
+// Любой другой вывод, падение или таймаут чекера - ошибка проверки
+
+// Режимы проверки
+const (
+	ModeExact           = "exact"
+	ModeTokens          = "tokens"
+	ModeCaseInsensitive = "case_insensitive"
+	ModeFloat           = "float"
+	ModeUnorderedLines  = "unordered_lines"
+	ModeWords           = "words" // слова без учета регистра и знаков препинания
+	ModeCustom          = "custom"
+)
+
+// defaultEpsilon - погрешность float, если в задаче не указана
+const defaultEpsilon = 1e-6
+
+// Файлы, которые получает программа-чекер
+const (
+	InputFile    = "input.txt"
+	ExpectedFile = "expected.txt"
+	ActualFile   = "actual.txt"
+)
+
+// Result - итог проверки одного вывода
+type Result struct {
+	Passed  bool
+	Message string // почему не принято (пусто, если принято)
+}
+
+// Compare проверяет actual встроенным режимом cfg.Mode.
+// Для custom и неизвестных режимов возвращает ошибку
+func Compare(cfg models.CheckerConfig, expected, actual string) (Result, error) {
+	expected = normalize(expected)
+	actual = normalize(actual)
+
+	switch cfg.Mode {
+	case "", ModeExact:
+		if actual != expected {
+			return Result{Message: "Вывод не совпадает с ожидаемым"}, nil
+		}
+	case ModeCaseInsensitive:
+		if !strings.EqualFold(actual, expected) {
+			return Result{Message: "Вывод не совпадает с ожидаемым (без учета регистра)"}, nil
+		}
+	case ModeTokens:
+		return compareTokens(expected, actual, func(want, got string) bool {
+			return want == got
+		}), nil
+	case ModeFloat:
+		absEps, relEps := cfg.AbsEpsilon, cfg.RelEpsilon
+		if absEps == 0 && relEps == 0 {
+			absEps = defaultEpsilon
+		}
+		return compareTokens(expected, actual, func(want, got string) bool {
+			return floatsEqual(want, got, absEps, relEps)
+		}), nil
+	case ModeUnorderedLines:
+		if !equalLines(sortedLines(expected), sortedLines(actual)) {
+			return Result{Message: "Набор строк не совпадает с ожидаемым"}, nil
+		}
+	case ModeWords:
+		if !equalLines(words(expected), words(actual)) {
+			return Result{Message: "Слова не совпадают с ожидаемыми (без учета регистра и знаков препинания)"}, nil
+		}
+	case ModeCustom:
+		return Result{}, fmt.Errorf("custom checker must be run with CustomJob")
+	default:
+		return Result{}, fmt.Errorf("unknown checker mode: %q", cfg.Mode)
+	}
+	return Result{Passed: true}, nil
+}
+
+// CustomJob - задание исполнителю на запуск программы-чекера
+func CustomJob(cfg models.CheckerConfig, input, expected, actual string) executor.Job {
+	return executor.Job{
+		Code:     cfg.Source,
+		Language: cfg.Language,
+		Files: map[string]string{
+			InputFile:    input,
+			ExpectedFile: expected,
+			ActualFile:   actual,
+		},
+	}
+}
+
+// CustomResult разбирает вывод программы-чекера. verdict - вердикт ее запуска
+func CustomResult(verdict, output string) (Result, error) {
+	if verdict != models.VerdictOK {
+		return Result{}, fmt.Errorf("checker failed: %s", verdict)
+	}
+
+	status, comment, _ := strings.Cut(normalize(output), "\n")
+	comment = strings.TrimSpace(comment)
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "OK":
+		return Result{Passed: true}, nil
+	case "WA":
+		if comment == "" {
+			comment = "Ответ не принят чекером"
+		}
+		return Result{Message: comment}, nil
+	default:
+		return Result{}, fmt.Errorf("checker printed %q instead of OK or WA", status)
+	}
+}
+
+// normalize убирает \r и пробельные символы по краям
+func normalize(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+// compareTokens сравнивает выводы по словам, игнорируя пробелы и переводы строк
+func compareTokens(expected, actual string, equal func(want, got string) bool) Result {
+	want := strings.Fields(expected)
+	got := strings.Fields(actual)
+
+	for i := 0; i < len(want) && i < len(got); i++ {
+		if !equal(want[i], got[i]) {
+			return Result{Message: fmt.Sprintf("Токен %d: ожидалось %q, получено %q", i+1, want[i], got[i])}
+		}
+	}
+	if len(want) != len(got) {
+		return Result{Message: fmt.Sprintf("Ожидалось токенов: %d, получено: %d", len(want), len(got))}
+	}
+	return Result{Passed: true}
+}
+
+// floatsEqual сравнивает числа с погрешностью, остальные токены - точно
+func floatsEqual(want, got string, absEps, relEps float64) bool {
+	expected, err1 := strconv.ParseFloat(want, 64)
+	actual, err2 := strconv.ParseFloat(got, 64)
+	if err1 != nil || err2 != nil {
+		return want == got
+	}
+	if math.IsNaN(expected) || math.IsNaN(actual) {
+		return math.IsNaN(expected) && math.IsNaN(actual)
+	}
+
+	diff := math.Abs(expected - actual)
+	return diff <= absEps || diff <= relEps*math.Abs(expected)
+}
+
+// sortedLines - строки без хвостовых пробелов в отсортированном порядке
+func sortedLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// words - слова из букв и цифр в нижнем регистре
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package checker
+
+import (
+	"backend/internal/models"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      models.CheckerConfig
+		expected string
+		actual   string
+		want     Result
+	}{
+		{
+			name:     "exact",
+			expected: "1 2\n3",
+			actual:   "1 2\n3",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "exact ignores CRLF and trailing newline",
+			expected: "1 2\n3\n",
+			actual:   "1 2\r\n3\r\n",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "exact mismatch",
+			expected: "1 2",
+			actual:   "1  2",
+			want:     Result{Message: "Вывод не совпадает с ожидаемым"},
+		},
+		{
+			name:     "case insensitive",
+			cfg:      models.CheckerConfig{Mode: ModeCaseInsensitive},
+			expected: "YES",
+			actual:   "yes",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "tokens ignore spacing",
+			cfg:      models.CheckerConfig{Mode: ModeTokens},
+			expected: "1 2\n3",
+			actual:   "1\t2   3\r\n",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "tokens mismatch",
+			cfg:      models.CheckerConfig{Mode: ModeTokens},
+			expected: "1 2 3",
+			actual:   "1 5 3",
+			want:     Result{Message: `Токен 2: ожидалось "2", получено "5"`},
+		},
+		{
+			name:     "tokens count mismatch",
+			cfg:      models.CheckerConfig{Mode: ModeTokens},
+			expected: "1 2 3",
+			actual:   "1 2",
+			want:     Result{Message: "Ожидалось токенов: 3, получено: 2"},
+		},
+		{
+			name:     "float default epsilon",
+			cfg:      models.CheckerConfig{Mode: ModeFloat},
+			expected: "0.333333",
+			actual:   "0.3333333",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "float default epsilon exceeded",
+			cfg:      models.CheckerConfig{Mode: ModeFloat},
+			expected: "0.3333",
+			actual:   "0.3334",
+			want:     Result{Message: `Токен 1: ожидалось "0.3333", получено "0.3334"`},
+		},
+		{
+			name:     "float absolute epsilon",
+			cfg:      models.CheckerConfig{Mode: ModeFloat, AbsEpsilon: 0.01},
+			expected: "1000",
+			actual:   "1000.005",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "float relative epsilon on large numbers",
+			cfg:      models.CheckerConfig{Mode: ModeFloat, RelEpsilon: 1e-3},
+			expected: "1000000",
+			actual:   "1000500",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "float relative epsilon is not absolute",
+			cfg:      models.CheckerConfig{Mode: ModeFloat, RelEpsilon: 1e-3},
+			expected: "0.001",
+			actual:   "0.0011",
+			want:     Result{Message: `Токен 1: ожидалось "0.001", получено "0.0011"`},
+		},
+		{
+			name:     "float NaN equals NaN",
+			cfg:      models.CheckerConfig{Mode: ModeFloat},
+			expected: "NaN",
+			actual:   "nan",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "float NaN is not a number",
+			cfg:      models.CheckerConfig{Mode: ModeFloat, AbsEpsilon: 1e9},
+			expected: "NaN",
+			actual:   "0",
+			want:     Result{Message: `Токен 1: ожидалось "NaN", получено "0"`},
+		},
+		{
+			name:     "float words compared exactly",
+			cfg:      models.CheckerConfig{Mode: ModeFloat},
+			expected: "answer 1.5",
+			actual:   "Answer 1.5",
+			want:     Result{Message: `Токен 1: ожидалось "answer", получено "Answer"`},
+		},
+		{
+			name:     "float token count mismatch",
+			cfg:      models.CheckerConfig{Mode: ModeFloat},
+			expected: "1.0 2.0",
+			actual:   "1.0 2.0 3.0",
+			want:     Result{Message: "Ожидалось токенов: 2, получено: 3"},
+		},
+		{
+			name:     "unordered lines",
+			cfg:      models.CheckerConfig{Mode: ModeUnorderedLines},
+			expected: "b 2\na 1\n",
+			actual:   "a 1  \r\nb 2",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "unordered lines count duplicates",
+			cfg:      models.CheckerConfig{Mode: ModeUnorderedLines},
+			expected: "a\na\nb",
+			actual:   "a\nb\nb",
+			want:     Result{Message: "Набор строк не совпадает с ожидаемым"},
+		},
+		{
+			name:     "words ignore punctuation and case",
+			cfg:      models.CheckerConfig{Mode: ModeWords},
+			expected: "Привет, мир! 42",
+			actual:   "привет мир 42.",
+			want:     Result{Passed: true},
+		},
+		{
+			name:     "words keep order",
+			cfg:      models.CheckerConfig{Mode: ModeWords},
+			expected: "hello, world",
+			actual:   "world hello",
+			want:     Result{Message: "Слова не совпадают с ожидаемыми (без учета регистра и знаков препинания)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare(tt.cfg, tt.expected, tt.actual)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Compare() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareUnsupportedMode(t *testing.T) {
+	for _, mode := range []string{ModeCustom, "regex"} {
+		if _, err := Compare(models.CheckerConfig{Mode: mode}, "1", "1"); err == nil {
+			t.Errorf("Compare() with mode %q: no error", mode)
+		}
+	}
+}
+
+func TestCustomResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		verdict string
+		output  string
+		want    Result
+		wantErr bool
+	}{
+		{name: "ok", verdict: models.VerdictOK, output: "OK\n", want: Result{Passed: true}},
+		{name: "ok in lower case with CRLF", verdict: models.VerdictOK, output: "ok\r\nвсе верно\r\n", want: Result{Passed: true}},
+		{name: "wrong answer with comment", verdict: models.VerdictOK, output: "WA\r\n  Сумма должна быть 10  \r\n", want: Result{Message: "Сумма должна быть 10"}},
+		{name: "wrong answer without comment", verdict: models.VerdictOK, output: "WA", want: Result{Message: "Ответ не принят чекером"}},
+		{name: "multiline comment", verdict: models.VerdictOK, output: "WA\nстрока 1\nстрока 2", want: Result{Message: "строка 1\nстрока 2"}},
+		{name: "unknown status", verdict: models.VerdictOK, output: "ACCEPTED", wantErr: true},
+		{name: "empty output", verdict: models.VerdictOK, output: "", wantErr: true},
+		{name: "checker crashed", verdict: models.VerdictRuntimeError, output: "OK", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CustomResult(tt.verdict, tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CustomResult() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CustomResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package compilecache
+
+import (
+	"archive/tar"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/utils"
+)
+
+// Кэш результатов сборки. Ключ - хэш языка, версии компилятора, команды
+// сборки и всех файлов задания, значение - файлы, которые сборка создала
+// в рабочем каталоге (бинарник, .class, .jar...). Одинаковый код собирается
+// один раз: повторные «Запустить» и тесты одной проверки берут готовое.
+// Записи лежат на диске архивами <key>.tar, при превышении размера
+// вытесняются давно не использованные
+
+// Cache - кэш сборок в каталоге dir размером не больше maxBytes
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // ключ -> элемент lru
+	lru     *list.List               // спереди - недавно использованные
+	size    int64
+	hits    int64
+	misses  int64
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// Stats - состояние кэша
+type Stats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// New открывает кэш в dir. Записи, оставшиеся от прошлых запусков,
+// подхватываются в порядке времени последнего использования
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create compile cache dir: %w", err)
+	}
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.tar"))
+	if err != nil {
+		return nil, err
+	}
+	type stored struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var found []stored
+	for _, name := range names {
+		key := strings.TrimSuffix(filepath.Base(name), ".tar")
+		info, err := os.Stat(name)
+		if err != nil || !info.Mode().IsRegular() || len(key) != sha256.Size*2 {
+			continue
+		}
+		found = append(found, stored{key, info.Size(), info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.After(found[j].modTime) })
+	for _, s := range found {
+		c.entries[s.key] = c.lru.PushBack(&entry{key: s.key, size: s.size})
+		c.size += s.size
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Key - ключ записи из частей. Части разделены длиной, поэтому
+// ("ab", "c") и ("a", "bc") дают разные ключи
+func Key(parts ...string) string {
+	hash := sha256.New()
+	var size [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		hash.Write(size[:])
+		io.WriteString(hash, part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get возвращает файлы сборки по ключу
+func (c *Cache) Get(key string) ([]utils.TarFile, bool) {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.mu.Unlock()
+
+	name := c.path(key)
+	data, err := os.ReadFile(name)
+	if err == nil {
+		var files []utils.TarFile
+		if files, err = readArchive(data); err == nil {
+			now := time.Now()
+			os.Chtimes(name, now, now) // порядок вытеснения переживает перезапуск
+			c.mu.Lock()
+			c.hits++
+			c.mu.Unlock()
+			return files, true
+		}
+	}
+
+	// Запись удалили с диска или она повреждена - забываем ее
+	log.Printf("⚠️ Compile cache entry %s is broken: %v", key[:12], err)
+	c.mu.Lock()
+	c.remove(key)
+	c.misses++
+	c.mu.Unlock()
+	return nil, false
+}
+
+// Put сохраняет файлы сборки. Запись больше всего кэша не сохраняется
+func (c *Cache) Put(key string, files []utils.TarFile) error {
+	archive, err := utils.CreateTarArchiveFiles(files)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return fmt.Errorf("build artifacts are too large: %d bytes (cache %d)", size, c.maxBytes)
+	}
+
+	// Пишем во временный файл и переименовываем: читатель не увидит половину записи
+	tmp, err := os.CreateTemp(c.dir, "put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.size -= elem.Value.(*entry).size
+		elem.Value.(*entry).size = size
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(&entry{key: key, size: size})
+	}
+	c.size += size
+	c.evict()
+	return nil
+}
+
+// Stats возвращает число записей, размер и попадания
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Entries: len(c.entries), Bytes: c.size, Hits: c.hits, Misses: c.misses}
+}
+
+// evict удаляет давно не использованные записи, пока кэш больше лимита.
+// Вызывается под mu
+func (c *Cache) evict() {
+	for c.size > c.maxBytes && c.lru.Len() > 0 {
+		oldest := c.lru.Back().Value.(*entry)
+		c.remove(oldest.key)
+	}
+}
+
+// remove удаляет запись из индекса и с диска. Вызывается под mu
+func (c *Cache) remove(key string) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.size -= elem.Value.(*entry).size
+	c.lru.Remove(elem)
+	delete(c.entries, key)
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️ Failed to remove compile cache entry %s: %v", key[:12], err)
+	}
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".tar")
+}
+
+// readArchive разбирает архив записи: только обычные файлы, с правами
+func readArchive(data []byte) ([]utils.TarFile, error) {
+	var files []utils.TarFile
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, utils.TarFile{Name: header.Name, Content: string(content), Mode: header.Mode})
+	}
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/drafts"
+	"backend/internal/models"
+)
+
+// DraftStore хранит черновики в code_drafts, историю - в code_draft_revisions
+type DraftStore struct {
+	db *sql.DB
+}
+
+func NewDraftStore(db *sql.DB) *DraftStore {
+	return &DraftStore{db: db}
+}
+
+// Save меняет черновик только при совпадении версии: проверка и запись -
+// один запрос, поэтому два одновременных сохранения не затрут друг друга
+func (s *DraftStore) Save(ctx context.Context, userID string, draft models.Draft, version int) (*models.Draft, error) {
+	files, err := nullJSON(draft.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	defer tx.Rollback()
+
+	draft.Version = version + 1
+	draft.UpdatedAt = time.Now()
+	var result sql.Result
+	if version == 0 {
+		result, err = tx.ExecContext(ctx, `
+			INSERT INTO code_drafts (user_id, task_id, language, code, files, version, updated_at)
+			VALUES ($1, $2, $3, $4, $5, 1, $6)
+			ON CONFLICT (user_id, task_id, language) DO NOTHING`,
+			userID, draft.TaskID, draft.Language, draft.Code, files, draft.UpdatedAt,
+		)
+	} else {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE code_drafts SET code = $4, files = $5, version = version + 1, updated_at = $6
+			WHERE user_id = $1 AND task_id = $2 AND language = $3 AND version = $7`,
+			userID, draft.TaskID, draft.Language, draft.Code, files, draft.UpdatedAt, version,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	if saved == 0 {
+		tx.Rollback()
+		current, err := s.Get(ctx, userID, draft.TaskID, draft.Language)
+		if errors.Is(err, drafts.ErrNotFound) {
+			return nil, drafts.ErrConflict
+		}
+		if err != nil {
+			return nil, err
+		}
+		return current, drafts.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO code_draft_revisions (user_id, task_id, language, version, code, files, saved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		userID, draft.TaskID, draft.Language, draft.Version, draft.Code, files, draft.UpdatedAt,
+	)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM code_draft_revisions
+			WHERE user_id = $1 AND task_id = $2 AND language = $3 AND version <= $4`,
+			userID, draft.TaskID, draft.Language, draft.Version-drafts.MaxRevisions,
+		)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	return &draft, nil
+}
+
+func (s *DraftStore) Revisions(ctx context.Context, userID, taskID, language string) ([]models.DraftRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT version, code, files, saved_at
+		FROM code_draft_revisions
+		WHERE user_id = $1 AND task_id = $2 AND language = $3
+		ORDER BY version DESC
+		LIMIT $4`,
+		userID, taskID, language, drafts.MaxRevisions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load draft revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.DraftRevision{}
+	for rows.Next() {
+		var revision models.DraftRevision
+		var files []byte
+		if err := rows.Scan(&revision.Version, &revision.Code, &files, &revision.SavedAt); err != nil {
+			return nil, fmt.Errorf("failed to load draft revisions: %w", err)
+		}
+		if err := unmarshalFiles(files, &revision.Files); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load draft revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func (s *DraftStore) Get(ctx context.Context, userID, taskID, language string) (*models.Draft, error) {
+	draft := models.Draft{TaskID: taskID, Language: language}
+	var files []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT code, files, version, updated_at
+		FROM code_drafts
+		WHERE user_id = $1 AND task_id = $2 AND language = $3`,
+		userID, taskID, language,
+	).Scan(&draft.Code, &files, &draft.Version, &draft.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, drafts.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load draft: %w", err)
+	}
+	if err := unmarshalFiles(files, &draft.Files); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// unmarshalFiles разбирает колонку files, NULL - файлов нет
+func unmarshalFiles(data []byte, files *[]models.SourceFile) error {
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, files); err != nil {
+		return fmt.Errorf("failed to parse files: %w", err)
+	}
+	return nil
+}
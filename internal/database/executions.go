@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+)
+
+// ExecutionStore сохраняет запуски кода в code_executions
+type ExecutionStore struct {
+	db *sql.DB
+}
+
+func NewExecutionStore(db *sql.DB) *ExecutionStore {
+	return &ExecutionStore{db: db}
+}
+
+// Save записывает запуск. Пустой ID генерируется.
+// execution_time - время по часам в миллисекундах
+func (s *ExecutionStore) Save(ctx context.Context, result *models.ExecutionResult) error {
+	if result.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		result.ID = id
+	}
+
+	tests, err := nullJSON(result.Tests)
+	if err != nil {
+		return err
+	}
+	files, err := nullJSON(result.Files)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO code_executions
+			(id, user_id, task_id, code, language, output, success, verdict,
+			 execution_time, cpu_time_ms, peak_memory_kb, image_digest, backend,
+			 kind, message, tests, files)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		result.ID, nullString(result.UserID), nullString(result.TaskID),
+		result.Code, result.Language, result.Output, result.Success, result.Verdict,
+		result.Stats.WallTimeMs, result.Stats.CPUTimeMs, result.Stats.PeakMemoryKB,
+		nullString(result.ImageDigest), nullString(result.Backend),
+		nullString(result.Kind), nullString(result.Message), tests, files,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save execution: %w", err)
+	}
+	return nil
+}
+
+// SubmissionFilter - какие посылки ученика выбрать. Пустые поля не фильтруют
+type SubmissionFilter struct {
+	UserID   string
+	TaskID   string
+	Language string
+	Verdict  string
+	Limit    int
+	Offset   int
+}
+
+// ListSubmissions возвращает проверки решений ученика, новые сначала
+func (s *ExecutionStore) ListSubmissions(ctx context.Context, filter SubmissionFilter) ([]models.SubmissionSummary, error) {
+	query := `
+		SELECT id, COALESCE(task_id, ''), language, success, COALESCE(verdict, ''), COALESCE(message, ''),
+			COALESCE(execution_time, 0), COALESCE(cpu_time_ms, 0), COALESCE(peak_memory_kb, 0), created_at
+		FROM code_executions
+		WHERE user_id = $1 AND kind = 'check'`
+	args := []interface{}{filter.UserID}
+	for _, condition := range []struct{ column, value string }{
+		{"task_id", filter.TaskID},
+		{"language", filter.Language},
+		{"verdict", filter.Verdict},
+	} {
+		if condition.value != "" {
+			args = append(args, condition.value)
+			query += fmt.Sprintf(" AND %s = $%d", condition.column, len(args))
+		}
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.SubmissionSummary{}
+	for rows.Next() {
+		var item models.SubmissionSummary
+		if err := rows.Scan(&item.ID, &item.TaskID, &item.Language, &item.Success, &item.Verdict, &item.Message,
+			&item.Stats.WallTimeMs, &item.Stats.CPUTimeMs, &item.Stats.PeakMemoryKB, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list submissions: %w", err)
+		}
+		submissions = append(submissions, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// ErrNotFound - записи нет
+var ErrNotFound = errors.New("not found")
+
+// Get возвращает запуск целиком, с кодом и результатами тестов
+func (s *ExecutionStore) Get(ctx context.Context, id string) (*models.ExecutionResult, error) {
+	var result models.ExecutionResult
+	var userID, taskID, output, verdict, digest, backend, kind, message sql.NullString
+	var wallTime, cpuTime, memory sql.NullInt64
+	var tests, files []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, task_id, code, language, output, success, verdict,
+			execution_time, cpu_time_ms, peak_memory_kb, image_digest, backend,
+			kind, message, tests, files, created_at
+		FROM code_executions
+		WHERE id = $1`,
+		id,
+	).Scan(&result.ID, &userID, &taskID, &result.Code, &result.Language, &output, &result.Success, &verdict,
+		&wallTime, &cpuTime, &memory, &digest, &backend,
+		&kind, &message, &tests, &files, &result.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution: %w", err)
+	}
+
+	result.UserID, result.TaskID, result.Output = userID.String, taskID.String, output.String
+	result.Verdict, result.ImageDigest, result.Backend = verdict.String, digest.String, backend.String
+	result.Kind, result.Message = kind.String, message.String
+	result.Stats = models.ExecutionStats{WallTimeMs: wallTime.Int64, CPUTimeMs: cpuTime.Int64, PeakMemoryKB: memory.Int64}
+	result.ExecutionTime = time.Duration(wallTime.Int64) * time.Millisecond
+	if tests != nil {
+		if err := json.Unmarshal(tests, &result.Tests); err != nil {
+			return nil, fmt.Errorf("failed to load execution tests: %w", err)
+		}
+	}
+	if files != nil {
+		if err := json.Unmarshal(files, &result.Files); err != nil {
+			return nil, fmt.Errorf("failed to load execution files: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+func newID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// nullJSON - значение для колонки JSONB, пустой срез - NULL
+func nullJSON[T any](items []T) (interface{}, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"backend/internal/leaderboard"
+)
+
+// LeaderboardSettingsStore хранит настройки участия в leaderboard_settings
+type LeaderboardSettingsStore struct {
+	db *sql.DB
+}
+
+func NewLeaderboardSettingsStore(db *sql.DB) *LeaderboardSettingsStore {
+	return &LeaderboardSettingsStore{db: db}
+}
+
+// List возвращает настройки всех учеников
+func (s *LeaderboardSettingsStore) List(ctx context.Context) ([]leaderboard.Settings, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, COALESCE(username, ''), COALESCE(display_name, ''), COALESCE(group_name, ''), hidden
+		FROM leaderboard_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leaderboard settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := []leaderboard.Settings{}
+	for rows.Next() {
+		var item leaderboard.Settings
+		if err := rows.Scan(&item.UserID, &item.Username, &item.Name, &item.Group, &item.Hidden); err != nil {
+			return nil, fmt.Errorf("failed to load leaderboard settings: %w", err)
+		}
+		settings = append(settings, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load leaderboard settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Save сохраняет настройки ученика. Имя пользователя не меняет
+func (s *LeaderboardSettingsStore) Save(ctx context.Context, settings leaderboard.Settings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO leaderboard_settings (user_id, display_name, group_name, hidden, updated_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			group_name = EXCLUDED.group_name,
+			hidden = EXCLUDED.hidden,
+			updated_at = EXCLUDED.updated_at`,
+		settings.UserID, settings.Name, settings.Group, settings.Hidden,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save leaderboard settings: %w", err)
+	}
+	return nil
+}
+
+// SaveUsername запоминает имя пользователя, настройки не меняет
+func (s *LeaderboardSettingsStore) SaveUsername(ctx context.Context, userID, username string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO leaderboard_settings (user_id, username)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET username = EXCLUDED.username`,
+		userID, username,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save leaderboard username: %w", err)
+	}
+	return nil
+}
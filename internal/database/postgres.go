@@ -96,6 +96,79 @@ func RunMigrations(db *sql.DB) error {
 			execution_time INTEGER,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
+
+		// Замеры запуска. Задачи и гостевые пользователи живут в памяти,
+		// поэтому внешние ключи на них мешают сохранять запуски
+		`ALTER TABLE code_executions
+			ADD COLUMN IF NOT EXISTS verdict VARCHAR(32),
+			ADD COLUMN IF NOT EXISTS cpu_time_ms INTEGER,
+			ADD COLUMN IF NOT EXISTS peak_memory_kb BIGINT,
+			DROP CONSTRAINT IF EXISTS code_executions_user_id_fkey,
+			DROP CONSTRAINT IF EXISTS code_executions_task_id_fkey`,
+
+		// Образ Docker запуска для воспроизводимости
+		`ALTER TABLE code_executions
+			ADD COLUMN IF NOT EXISTS image_digest VARCHAR(255)`,
+
+		// Исполнитель запуска: docker или local
+		`ALTER TABLE code_executions
+			ADD COLUMN IF NOT EXISTS backend VARCHAR(16)`,
+
+		// История посылок: проверка или запуск, итог и результаты тестов
+		`ALTER TABLE code_executions
+			ADD COLUMN IF NOT EXISTS kind VARCHAR(16),
+			ADD COLUMN IF NOT EXISTS message TEXT,
+			ADD COLUMN IF NOT EXISTS tests JSONB,
+			ADD COLUMN IF NOT EXISTS files JSONB`,
+		`CREATE INDEX IF NOT EXISTS code_executions_user_created_idx
+			ON code_executions (user_id, created_at DESC)`,
+
+		// Прогресс пишется для гостей и задач из памяти, внешние ключи мешают
+		`ALTER TABLE user_progress
+			DROP CONSTRAINT IF EXISTS user_progress_user_id_fkey,
+			DROP CONSTRAINT IF EXISTS user_progress_task_id_fkey`,
+
+		// Время последнего улучшения - для таблиц лидеров. Старым записям
+		// подходит время последней попытки
+		`ALTER TABLE user_progress
+			ADD COLUMN IF NOT EXISTS improved_at TIMESTAMP`,
+		`UPDATE user_progress SET improved_at = last_attempt
+			WHERE improved_at IS NULL AND (completed OR best_score > 0)`,
+
+		// Настройки участия в таблицах лидеров
+		`CREATE TABLE IF NOT EXISTS leaderboard_settings (
+			user_id VARCHAR(36) PRIMARY KEY,
+			display_name VARCHAR(50),
+			group_name VARCHAR(50),
+			hidden BOOLEAN NOT NULL DEFAULT false,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Черновики кода и последние сохранения
+		`CREATE TABLE IF NOT EXISTS code_drafts (
+			user_id VARCHAR(36) NOT NULL,
+			task_id VARCHAR(36) NOT NULL,
+			language VARCHAR(20) NOT NULL,
+			code TEXT NOT NULL,
+			files JSONB,
+			version INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, task_id, language)
+		)`,
+		`CREATE TABLE IF NOT EXISTS code_draft_revisions (
+			user_id VARCHAR(36) NOT NULL,
+			task_id VARCHAR(36) NOT NULL,
+			language VARCHAR(20) NOT NULL,
+			version INTEGER NOT NULL,
+			code TEXT NOT NULL,
+			files JSONB,
+			saved_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (user_id, task_id, language, version)
+		)`,
+
+		// Имя пользователя для таблиц лидеров: пользователи живут в памяти
+		// и после перезапуска имя берется отсюда
+		`ALTER TABLE leaderboard_settings ADD COLUMN IF NOT EXISTS username VARCHAR(50)`,
 	}
 
 	for i, migration := range migrations {
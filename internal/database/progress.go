@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+)
+
+// ProgressStore хранит прогресс учеников в user_progress
+type ProgressStore struct {
+	db *sql.DB
+}
+
+func NewProgressStore(db *sql.DB) *ProgressStore {
+	return &ProgressStore{db: db}
+}
+
+// Record учитывает посылку одним запросом: параллельные посылки
+// одного ученика не теряют попытки
+func (s *ProgressStore) Record(ctx context.Context, userID, taskID string, passed bool, score float64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_progress (user_id, task_id, completed, attempts, best_score, last_attempt, improved_at)
+		VALUES ($1, $2, $3, 1, $4, $5, CASE WHEN $3 OR $4 > 0 THEN $5::timestamp END)
+		ON CONFLICT (user_id, task_id) DO UPDATE SET
+			completed = user_progress.completed OR EXCLUDED.completed,
+			attempts = user_progress.attempts + 1,
+			best_score = GREATEST(user_progress.best_score, EXCLUDED.best_score),
+			last_attempt = EXCLUDED.last_attempt,
+			improved_at = CASE
+				WHEN (EXCLUDED.completed AND NOT user_progress.completed) OR EXCLUDED.best_score > user_progress.best_score
+				THEN EXCLUDED.last_attempt
+				ELSE user_progress.improved_at
+			END`,
+		userID, taskID, passed, score, at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// List возвращает прогресс ученика по задачам
+func (s *ProgressStore) List(ctx context.Context, userID string) ([]models.UserProgress, error) {
+	return s.query(ctx, `WHERE user_id = $1 ORDER BY task_id`, userID)
+}
+
+// All возвращает прогресс всех учеников (для таблиц лидеров)
+func (s *ProgressStore) All(ctx context.Context) ([]models.UserProgress, error) {
+	return s.query(ctx, `ORDER BY user_id, task_id`)
+}
+
+func (s *ProgressStore) query(ctx context.Context, where string, args ...interface{}) ([]models.UserProgress, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, task_id, completed, attempts, best_score, last_attempt, improved_at
+		FROM user_progress `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.UserProgress{}
+	for rows.Next() {
+		var record models.UserProgress
+		var lastAttempt, improvedAt sql.NullTime
+		if err := rows.Scan(&record.UserID, &record.TaskID, &record.Completed, &record.Attempts, &record.BestScore, &lastAttempt, &improvedAt); err != nil {
+			return nil, fmt.Errorf("failed to load progress: %w", err)
+		}
+		record.LastAttempt = lastAttempt.Time
+		record.ImprovedAt = improvedAt.Time
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+)
+
+// Построчное сравнение текстов по наибольшей общей подпоследовательности.
+// Общие начало и конец отрезаются сразу, таблица строится только для
+// середины, где тексты различаются
+
+// Операции строки
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// MaxCells - наибольший размер таблицы LCS (строки старого текста на
+// строки нового после отрезания общих начала и конца)
+const MaxCells = 4_000_000
+
+// ErrTooLarge - тексты слишком сильно различаются для сравнения
+var ErrTooLarge = errors.New("texts are too large to compare")
+
+// Line - строка результата. Old и New - номера строки в старом и новом
+// тексте с 1, 0 - строки там нет
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+	Old  int    `json:"old,omitempty"`
+	New  int    `json:"new,omitempty"`
+}
+
+// Lines сравнивает old и new построчно
+func Lines(old, new string) ([]Line, error) {
+	a, b := split(old), split(new)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) > MaxCells {
+		return nil, ErrTooLarge
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, Line{Op: Equal, Text: a[i], Old: i + 1, New: i + 1})
+	}
+	lines = appendMiddle(lines, midA, midB, prefix)
+	for i := 0; i < suffix; i++ {
+		oldIndex, newIndex := len(a)-suffix+i, len(b)-suffix+i
+		lines = append(lines, Line{Op: Equal, Text: a[oldIndex], Old: oldIndex + 1, New: newIndex + 1})
+	}
+	return lines, nil
+}
+
+// appendMiddle сравнивает различающуюся середину. offset - сколько строк
+// было до нее в обоих текстах
+func appendMiddle(lines []Line, a, b []string, offset int) []Line {
+	n, m := len(a), len(b)
+	// lcs[i*(m+1)+j] - длина общей подпоследовательности a[i:] и b[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			lines = append(lines, Line{Op: Equal, Text: a[i], Old: offset + i + 1, New: offset + j + 1})
+			i++
+			j++
+		case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			lines = append(lines, Line{Op: Delete, Text: a[i], Old: offset + i + 1})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: b[j], New: offset + j + 1})
+			j++
+		}
+	}
+	return lines
+}
+
+// Count - сколько строк добавлено и удалено
+func Count(lines []Line) (added, removed int) {
+	for _, line := range lines {
+		switch line.Op {
+		case Insert:
+			added++
+		case Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// split делит текст на строки. Перевод строки в конце новой строки не дает,
+// \r\n считается одним переводом
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func eq(text string, old, new int) Line { return Line{Op: Equal, Text: text, Old: old, New: new} }
+func ins(text string, new int) Line     { return Line{Op: Insert, Text: text, New: new} }
+func del(text string, old int) Line     { return Line{Op: Delete, Text: text, Old: old} }
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		old, new    string
+		want        []Line
+		wantAdded   int
+		wantRemoved int
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: []Line{eq("a", 1, 1), eq("b", 2, 2)},
+		},
+		{
+			name: "both empty",
+			want: []Line{},
+		},
+		{
+			name:      "insert into empty",
+			new:       "a\nb",
+			want:      []Line{ins("a", 1), ins("b", 2)},
+			wantAdded: 2,
+		},
+		{
+			name:      "insert in the middle",
+			old:       "a\nc\n",
+			new:       "a\nb\nc\n",
+			want:      []Line{eq("a", 1, 1), ins("b", 2), eq("c", 2, 3)},
+			wantAdded: 1,
+		},
+		{
+			name:        "delete only",
+			old:         "a\nb\nc\nd",
+			new:         "a\nd",
+			want:        []Line{eq("a", 1, 1), del("b", 2), del("c", 3), eq("d", 4, 2)},
+			wantRemoved: 2,
+		},
+		{
+			name:        "delete everything",
+			old:         "a\nb",
+			want:        []Line{del("a", 1), del("b", 2)},
+			wantRemoved: 2,
+		},
+		{
+			name: "mixed",
+			old:  "head\nx\nsame\ny\ntail",
+			new:  "head\nsame\nz\nw\ntail",
+			want: []Line{
+				eq("head", 1, 1), del("x", 2), eq("same", 3, 2), del("y", 4), ins("z", 3), ins("w", 4), eq("tail", 5, 5),
+			},
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name: "CRLF equals LF",
+			old:  "a\r\nb\r\n",
+			new:  "a\nb\n",
+			want: []Line{eq("a", 1, 1), eq("b", 2, 2)},
+		},
+		{
+			name:      "CRLF with a change",
+			old:       "a\r\nb\r\n",
+			new:       "a\nb\nc\n",
+			want:      []Line{eq("a", 1, 1), eq("b", 2, 2), ins("c", 3)},
+			wantAdded: 1,
+		},
+		{
+			name: "trailing newline is not a line",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: []Line{eq("a", 1, 1), eq("b", 2, 2)},
+		},
+		{
+			name:      "empty last line is a line",
+			old:       "a\n",
+			new:       "a\n\n",
+			want:      []Line{eq("a", 1, 1), ins("", 2)},
+			wantAdded: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(tt.old, tt.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lines() = %+v, want %+v", got, tt.want)
+			}
+			added, removed := Count(got)
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("Count() = +%d -%d, want +%d -%d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+// numbered - n разных строк с префиксом
+func numbered(prefix string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(prefix)
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteByte(byte('a' + i%26))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestLinesTooLarge(t *testing.T) {
+	// Середина 2001 x 2000 строк больше MaxCells
+	old := "same\n" + numbered("old ", 2001) + "tail\n"
+	new := "same\n" + numbered("new ", 2000) + "tail\n"
+	if _, err := Lines(old, new); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Lines() error = %v, want ErrTooLarge", err)
+	}
+
+	// Общие начало и конец в таблицу не входят
+	common := numbered("same ", 3000)
+	lines, err := Lines(common+"old\n"+common, common+"new\n"+common)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, removed := Count(lines); added != 1 || removed != 1 {
+		t.Errorf("Count() = +%d -%d, want +1 -1", added, removed)
+	}
+}
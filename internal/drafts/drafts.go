@@ -0,0 +1,33 @@
+package drafts
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+)
+
+// Черновики кода: редактор сохраняет код на сервер, чтобы он пережил
+// перезагрузку страницы. Каждое сохранение передает версию, от которой
+// шла правка: если черновик успели сохранить с другой вкладки, версии
+// не совпадут и сохранение отклоняется. Последние MaxRevisions
+// сохранений хранятся как история
+
+// MaxRevisions - сколько последних сохранений хранится
+const MaxRevisions = 10
+
+var (
+	ErrNotFound = errors.New("draft not found")
+	ErrConflict = errors.New("draft was changed by another save")
+)
+
+// Store - хранилище черновиков
+type Store interface {
+	// Get возвращает черновик или ErrNotFound
+	Get(ctx context.Context, userID, taskID, language string) (*models.Draft, error)
+	// Save сохраняет черновик, если сейчас у него версия version (0 - черновика
+	// нет), и возвращает его с новой версией. ErrConflict - версия другая,
+	// вместе с ним возвращается текущий черновик (nil, если его нет)
+	Save(ctx context.Context, userID string, draft models.Draft, version int) (*models.Draft, error)
+	// Revisions возвращает последние сохранения, новые сначала
+	Revisions(ctx context.Context, userID, taskID, language string) ([]models.DraftRevision, error)
+}
@@ -0,0 +1,84 @@
+package drafts
+
+import (
+	"backend/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore - черновики в памяти процесса, когда база не настроена
+type MemoryStore struct {
+	mu     sync.Mutex
+	drafts map[key]*entry
+}
+
+type key struct {
+	userID, taskID, language string
+}
+
+type entry struct {
+	draft     models.Draft
+	revisions []models.DraftRevision // новые сначала
+}
+
+// NewMemoryStore создает пустое хранилище
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{drafts: make(map[key]*entry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, userID, taskID, language string) (*models.Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.drafts[key{userID, taskID, language}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	draft := e.draft
+	return &draft, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, userID string, draft models.Draft, version int) (*models.Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := key{userID, draft.TaskID, draft.Language}
+	e, ok := s.drafts[k]
+	current := 0
+	if ok {
+		current = e.draft.Version
+	}
+	if current != version {
+		if !ok {
+			return nil, ErrConflict
+		}
+		existing := e.draft
+		return &existing, ErrConflict
+	}
+	if !ok {
+		e = &entry{}
+		s.drafts[k] = e
+	}
+
+	draft.Version = version + 1
+	draft.UpdatedAt = time.Now()
+	e.draft = draft
+	revision := models.DraftRevision{Version: draft.Version, Code: draft.Code, Files: draft.Files, SavedAt: draft.UpdatedAt}
+	e.revisions = append([]models.DraftRevision{revision}, e.revisions...)
+	if len(e.revisions) > MaxRevisions {
+		e.revisions = e.revisions[:MaxRevisions]
+	}
+	return &draft, nil
+}
+
+func (s *MemoryStore) Revisions(ctx context.Context, userID, taskID, language string) ([]models.DraftRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := []models.DraftRevision{}
+	if e, ok := s.drafts[key{userID, taskID, language}]; ok {
+		revisions = append(revisions, e.revisions...)
+	}
+	return revisions, nil
+}
@@ -1,7 +1,82 @@
 package executor
 
+import (
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Executor выполняет код. Отмена ctx (например, клиент отключился)
+// сразу останавливает выполнение
 type Executor interface {
-	Execute(code, language string) (map[string]interface{}, error)
+	Execute(ctx context.Context, job Job) (map[string]interface{}, error)
 }
 
 // Контракт исполнителя, короче Абстракция
+
+// Job - что выполнить и с какими лимитами. Передается воркерам в JSON
+type Job struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	// Limits - уже пересчитанные под язык лимиты (см. EffectiveLimits).
+	// Нулевые поля - лимиты языка и сервера по умолчанию
+	Limits models.TaskLimits `json:"limits"`
+	// Files - остальные файлы решения: относительный путь через / - содержимое.
+	// Основной файл языка можно передать здесь же вместо Code
+	Files map[string]string `json:"files,omitempty"`
+	// ReadOnlyFiles - файлы задачи (заготовки, данные, тесты), кладутся
+	// поверх файлов решения и недоступны программе для записи
+	ReadOnlyFiles map[string]string `json:"read_only_files,omitempty"`
+	// RunFiles - файлы только для запуска (например, номер теста). Кладутся
+	// после сборки и не входят в ключ кэша сборки, поэтому одна сборка
+	// годится для запусков с разными RunFiles
+	RunFiles map[string]string `json:"run_files,omitempty"`
+	// Unit - запустить юнит-тесты языка (файл тестов передается в Files)
+	// и вернуть отчет фреймворка в report
+	Unit bool `json:"unit,omitempty"`
+	// Stdin - ввод программы для Execute (ввод теста). Потоковый запуск
+	// берет ввод из Streams.Stdin
+	Stdin string `json:"stdin,omitempty"`
+}
+
+// StdinReader - ввод задания, nil - без ввода
+func (j Job) StdinReader() io.Reader {
+	if j.Stdin == "" {
+		return nil
+	}
+	return strings.NewReader(j.Stdin)
+}
+
+// Streams - потоки ввода-вывода запущенной программы.
+// Stdout и Stderr получают данные по мере того, как программа их пишет.
+// Если Stdin задан, программа читает из него (интерактивная сессия, ввод теста)
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// StreamExecutor - исполнитель, умеющий отдавать вывод по частям
+type StreamExecutor interface {
+	ExecuteStream(ctx context.Context, job Job, streams Streams) (map[string]interface{}, error)
+}
+
+// WithDefaultTimeout ограничивает ctx таймаутом по умолчанию,
+// если вызывающий не задал свой дедлайн (например, для интерактивной сессии)
+func WithDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TimeoutMessage - текст ошибки для ctx, завершившегося по дедлайну или отмене
+func TimeoutMessage(ctx context.Context, started time.Time) string {
+	if ctx.Err() == context.Canceled {
+		return "Execution cancelled"
+	}
+	return fmt.Sprintf("Execution timeout (%v exceeded)", time.Since(started).Round(time.Second))
+}
@@ -0,0 +1,216 @@
+package executor
+
+import (
+	"backend/internal/compilecache"
+	"backend/internal/languages"
+	"backend/internal/utils"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResolveLanguage - чем выполнять задание: для юнит-тестов - вариант языка с фреймворком
+func (job Job) ResolveLanguage(lang *languages.Language) (*languages.Language, bool) {
+	if !job.Unit {
+		return lang, true
+	}
+	return lang.UnitVariant()
+}
+
+// ReadReport читает отчет тестового фреймворка из dir, не больше limit байт.
+// Пустая строка - отчета нет (фреймворк пишет в stdout или не запустился)
+func ReadReport(dir string, lang *languages.Language, limit int64) string {
+	if lang.Unit == nil || lang.Unit.Report == "" {
+		return ""
+	}
+	file, err := os.Open(filepath.Join(dir, filepath.FromSlash(lang.Unit.Report)))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Ограничения на файлы одного запуска
+const (
+	MaxFiles     = 64
+	MaxFilesSize = 1024 * 1024 // суммарно, байт
+	// MaxArtifactsSize - сколько байт сборки сохраняется в кэш
+	MaxArtifactsSize = 64 * 1024 * 1024
+)
+
+// Validate проверяет пути и размер файлов задания
+func (job Job) Validate() error {
+	if count := len(job.Files) + len(job.ReadOnlyFiles) + len(job.RunFiles); count > MaxFiles {
+		return fmt.Errorf("too many files: %d (max %d)", count, MaxFiles)
+	}
+	size := len(job.Code)
+	for _, files := range []map[string]string{job.Files, job.ReadOnlyFiles, job.RunFiles} {
+		for name, content := range files {
+			if err := validatePath(name); err != nil {
+				return err
+			}
+			size += len(content)
+		}
+	}
+	if size > MaxFilesSize {
+		return fmt.Errorf("files are too large: %d bytes (max %d)", size, MaxFilesSize)
+	}
+	return nil
+}
+
+// validatePath - относительный путь через /, без .. и скрытых файлов.
+// Только буквы, цифры и -_. - пути попадают в команды сборки в Docker
+func validatePath(name string) error {
+	if name == "" || len(name) > 255 || path.IsAbs(name) || path.Clean(name) != name {
+		return fmt.Errorf("invalid file path: %q", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return fmt.Errorf("invalid file path: %q", name)
+		}
+	}
+	for _, r := range name {
+		safe := r == '/' || r == '-' || r == '_' || r == '.' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !safe {
+			return fmt.Errorf("invalid character in file path: %q", name)
+		}
+	}
+	return nil
+}
+
+// Tree - дерево задания: код, файлы языка, файлы решения и поверх них
+// файлы задачи только для чтения. Пути через /, отсортированы
+func (job Job) Tree(lang *languages.Language) ([]utils.TarFile, error) {
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	tree := map[string]utils.TarFile{
+		lang.FileName: {Name: lang.FileName, Content: job.Code, Mode: 0644},
+	}
+	for name, content := range lang.ExtraFiles {
+		tree[name] = utils.TarFile{Name: name, Content: content, Mode: 0644}
+	}
+	for name, content := range job.Files {
+		tree[name] = utils.TarFile{Name: name, Content: content, Mode: 0644}
+	}
+	// Файл задачи заменяет одноименный файл решения
+	for name, content := range job.ReadOnlyFiles {
+		tree[name] = utils.TarFile{Name: name, Content: content, Mode: 0444}
+	}
+
+	files := make([]utils.TarFile, 0, len(tree))
+	for _, file := range tree {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// RunTree - файлы только для запуска, только для чтения. Пути отсортированы
+func (job Job) RunTree() []utils.TarFile {
+	files := make([]utils.TarFile, 0, len(job.RunFiles))
+	for name, content := range job.RunFiles {
+		files = append(files, utils.TarFile{Name: name, Content: content, Mode: 0444})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// WriteTree раскладывает файлы в dir
+func WriteTree(dir string, files []utils.TarFile) error {
+	for _, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %v", file.Name, err)
+		}
+		// Одноименный файл может быть только для чтения - заменяем его целиком
+		os.Remove(target)
+		if err := os.WriteFile(target, []byte(file.Content), os.FileMode(file.Mode)); err != nil {
+			return fmt.Errorf("failed to write %s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// Artifacts - файлы, которые сборка добавила в dir к дереву задания tree.
+// Больше limit байт суммарно - ошибка
+func Artifacts(dir string, tree []utils.TarFile, limit int64) ([]utils.TarFile, error) {
+	known := make(map[string]bool, len(tree))
+	for _, file := range tree {
+		known[file.Name] = true
+	}
+
+	var artifacts []utils.TarFile
+	var size int64
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if known[rel] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if size += info.Size(); size > limit {
+			return fmt.Errorf("build artifacts are larger than %d bytes", limit)
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, utils.TarFile{Name: rel, Content: string(content), Mode: int64(info.Mode().Perm())})
+		return nil
+	})
+	return artifacts, err
+}
+
+// CompileKey - ключ кэша сборки: язык, версия тулчейна, команда сборки
+// (шаблон, без путей конкретного запуска) и дерево задания
+func CompileKey(lang *languages.Language, toolchain string, compile []string, tree []utils.TarFile) string {
+	parts := []string{lang.ID, toolchain, strings.Join(compile, "\x00"), strings.Join(lang.CompileEnv, "\x00")}
+	for _, file := range tree {
+		parts = append(parts, file.Name, strconv.FormatInt(file.Mode, 8), file.Content)
+	}
+	return compilecache.Key(parts...)
+}
+
+// Sources - пути исходников языка в дереве задания (по расширению
+// основного файла), основной файл первым. Файл ученика с именем файла
+// задачи в дереве один (файл задачи кладется поверх), поэтому и в списке один
+func (job Job) Sources(lang *languages.Language) []string {
+	ext := path.Ext(lang.FileName)
+	sources := []string{lang.FileName}
+	seen := map[string]bool{lang.FileName: true}
+	var rest []string
+	for _, files := range []map[string]string{job.Files, job.ReadOnlyFiles} {
+		for name := range files {
+			if !seen[name] && path.Ext(name) == ext {
+				seen[name] = true
+				rest = append(rest, name)
+			}
+		}
+	}
+	sort.Strings(rest)
+	return append(sources, rest...)
+}
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"backend/internal/languages"
+	"backend/internal/models"
+	"time"
+)
+
+// EffectiveLimits - лимиты задачи для языка lang: заданные в задаче лимиты
+// умножаются на множители языка, незаданные берутся из настроек языка.
+// outputLimit - лимит вывода сервера по умолчанию в байтах
+func EffectiveLimits(task models.TaskLimits, lang *languages.Language, outputLimit int64) models.TaskLimits {
+	limits := models.TaskLimits{
+		TimeLimitMs:   int(lang.Timeout() / time.Millisecond),
+		MemoryLimitMB: lang.Limits.MemoryMB,
+		OutputLimitKB: int(outputLimit / 1024),
+	}
+
+	if task.TimeLimitMs > 0 {
+		limits.TimeLimitMs = int(lang.ScaleTime(time.Duration(task.TimeLimitMs)*time.Millisecond) / time.Millisecond)
+	}
+	if task.MemoryLimitMB > 0 {
+		limits.MemoryLimitMB = lang.ScaleMemory(task.MemoryLimitMB)
+	}
+	if task.OutputLimitKB > 0 {
+		limits.OutputLimitKB = task.OutputLimitKB
+	}
+	return limits
+}
+
+// TimeLimit - сколько может работать программа
+func (job Job) TimeLimit(lang *languages.Language) time.Duration {
+	if job.Limits.TimeLimitMs > 0 {
+		return time.Duration(job.Limits.TimeLimitMs) * time.Millisecond
+	}
+	return lang.Timeout()
+}
+
+// MemoryLimitMB - сколько памяти дать программе (0 - по умолчанию исполнителя)
+func (job Job) MemoryLimitMB(lang *languages.Language) int {
+	if job.Limits.MemoryLimitMB > 0 {
+		return job.Limits.MemoryLimitMB
+	}
+	return lang.Limits.MemoryMB
+}
+
+// OutputLimit - сколько байт может вывести программа, fallback - лимит исполнителя
+func (job Job) OutputLimit(fallback int64) int64 {
+	if job.Limits.OutputLimitKB > 0 {
+		return int64(job.Limits.OutputLimitKB) * 1024
+	}
+	return fallback
+}
@@ -1,267 +1,440 @@
 package executor
 
 import (
+	"backend/internal/compilecache"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"backend/internal/sandbox"
+	"backend/internal/utils"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
-type LocalExecutor struct{}
+// CompileMemoryMB - память компилятора, локально и в контейнере сборки.
+// Лимит памяти задачи относится к программе, компилятору его мало
+const CompileMemoryMB = 1024
 
-func NewLocalExecutor() *LocalExecutor {
-	return &LocalExecutor{}
+// compileLimits - лимиты компилятора в песочнице, они щедрее лимитов программы
+var compileLimits = sandbox.Limits{
+	CPUSeconds:   30,
+	FileSizeMB:   64,
+	MaxProcesses: 64,
+	MemoryMB:     CompileMemoryMB,
 }
 
-func (e *LocalExecutor) Execute(code, language string) (map[string]interface{}, error) {
-	log.Printf("🎯 LocalExecutor executing %s code", language)
-
-	switch strings.ToLower(language) {
-	case "go":
-		return e.executeGo(code)
-	case "python", "python3":
-		return e.executePython(code)
-	case "javascript", "node":
-		return e.executeJavaScript(code)
-	case "cpp", "c++":
-		return e.executeCpp(code)
-	case "java":
-		return e.executeJava(code)
-	default:
-		return map[string]interface{}{
-			"output":   "Hello World\n", // СИМУЛЯЦИЯ для неизвестных языков
-			"error":    "",
-			"exitCode": 0,
-		}, nil
-	}
+type LocalExecutor struct {
+	languages    *languages.Registry
+	sandbox      *sandbox.Sandbox    // nil - процессы запускаются напрямую от пользователя сервера
+	outputLimit  int64               // сколько байт stdout и stderr разрешено программе
+	cacheDir     string              // общий для всех запусков кэш сборки ({cache} в командах)
+	compileCache *compilecache.Cache // nil - каждый запуск собирается заново
+	toolchains   sync.Map            // отпечаток программы сборки -> отпечаток с версией
 }
 
-func (e *LocalExecutor) executeGo(code string) (map[string]interface{}, error) {
-	return map[string]interface{}{
-		"output":   "Hello World\n",
-		"error":    "",
-		"exitCode": 0,
-	}, nil
+func NewLocalExecutor(registry *languages.Registry) *LocalExecutor {
+	return &LocalExecutor{
+		languages:   registry,
+		outputLimit: DefaultOutputLimit,
+		cacheDir:    filepath.Join(os.TempDir(), "trenager-cache"),
+	}
 }
 
-func (e *LocalExecutor) executePython(code string) (map[string]interface{}, error) {
-	log.Printf("🐍 Executing Python code for real")
+// NewSandboxedLocalExecutor создает исполнитель, который запускает
+// компиляторы и программы в песочнице sb
+func NewSandboxedLocalExecutor(registry *languages.Registry, sb *sandbox.Sandbox) *LocalExecutor {
+	e := NewLocalExecutor(registry)
+	e.sandbox = sb
+	return e
+}
 
-	// Создаем временный файл
-	tmpFile, err := os.CreateTemp("", "python_*.py")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %v", err)
+// SetOutputLimit задает лимит вывода программы в байтах
+func (e *LocalExecutor) SetOutputLimit(limit int64) {
+	if limit > 0 {
+		e.outputLimit = limit
 	}
-	defer os.Remove(tmpFile.Name())
+}
 
-	if _, err := tmpFile.Write([]byte(code)); err != nil {
-		return nil, fmt.Errorf("failed to write code: %v", err)
+// SetCacheDir задает каталог для общего кэша сборки.
+// Без него, например, каждая сборка на Go заново компилирует стандартную библиотеку
+func (e *LocalExecutor) SetCacheDir(dir string) {
+	if dir != "" {
+		e.cacheDir = dir
 	}
-	tmpFile.Close()
+}
 
-	// ИСПРАВЬ КОМАНДУ: python3 → python (для Windows)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// SetCompileCache включает кэш собранных программ
+func (e *LocalExecutor) SetCompileCache(cache *compilecache.Cache) {
+	e.compileCache = cache
+}
 
-	cmd := exec.CommandContext(ctx, "python", tmpFile.Name()) // ← ИЗМЕНИЛ python3 на python
+// Execute выполняет код и возвращает весь вывод разом
+func (e *LocalExecutor) Execute(ctx context.Context, job Job) (map[string]interface{}, error) {
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
 
-	err = cmd.Run()
-
-	if ctx.Err() == context.DeadlineExceeded {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (30 seconds exceeded)",
-			"exitCode": 1,
-		}, nil
+	result, err := e.ExecuteStream(ctx, job, Streams{Stdin: job.StdinReader(), Stdout: &stdout, Stderr: &stderr})
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil {
-		return map[string]interface{}{
-			"output":   stdout.String(),
-			"error":    stderr.String(),
-			"exitCode": 1,
-		}, nil
+	result["output"] = stdout.String()
+	// Ошибки компиляции, таймауты и лимиты уже лежат в "error"
+	if result["error"].(string) == "" && result["exitCode"].(int) != 0 {
+		result["error"] = stderr.String()
 	}
 
-	return map[string]interface{}{
-		"output":   stdout.String(),
-		"error":    "",
-		"exitCode": 0,
-	}, nil
+	return result, nil
 }
 
-func (e *LocalExecutor) executeJavaScript(code string) (map[string]interface{}, error) {
-	// Реальное выполнение JavaScript (оно работает)
-	tmpFile, err := os.CreateTemp("", "javascript_*.js")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %v", err)
+// ExecuteStream выполняет код, отдавая stdout и stderr в streams по мере появления.
+// Отмена ctx завершает программу вместе с потомками. Возвращает verdict, exitCode
+// и error (ошибка компиляции, таймаут, лимит), без output
+func (e *LocalExecutor) ExecuteStream(ctx context.Context, job Job, streams Streams) (map[string]interface{}, error) {
+	lang, ok := e.languages.Lookup(job.Language)
+	if !ok {
+		return executionResult(models.VerdictUnsupported, "Unsupported language: "+job.Language, 1), nil
 	}
-	defer os.Remove(tmpFile.Name())
+	if lang, ok = job.ResolveLanguage(lang); !ok {
+		return executionResult(models.VerdictUnsupported, "Unit tests are not supported for "+job.Language, 1), nil
+	}
+
+	log.Printf("🎯 LocalExecutor executing %s code", lang.ID)
 
-	if _, err := tmpFile.Write([]byte(code)); err != nil {
-		return nil, fmt.Errorf("failed to write code: %v", err)
+	// Создаем временную директорию
+	tmpDir, err := os.MkdirTemp("", lang.ID+"_exec_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %v", err)
 	}
-	tmpFile.Close()
+	defer os.RemoveAll(tmpDir)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	tree, err := job.Tree(lang)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteTree(tmpDir, tree); err != nil {
+		return nil, err
+	}
 
-	cmd := exec.CommandContext(ctx, "node", tmpFile.Name())
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	vars := lang.VarsFor(tmpDir, e.cacheDir).WithSources(job.Sources(lang))
 
-	err = cmd.Run()
+	// Компилируем
+	if lang.Compiled() {
+		if result, err := e.build(ctx, tmpDir, lang, vars, tree); result != nil || err != nil {
+			return result, err
+		}
+	}
+	if err := WriteTree(tmpDir, job.RunTree()); err != nil {
+		return nil, err
+	}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (30 seconds exceeded)",
-			"exitCode": 1,
-		}, nil
+	// Выполняем
+	timeout := job.TimeLimit(lang)
+	outputLimit := job.OutputLimit(e.outputLimit)
+	result, err := e.runProgram(ctx, program{
+		dir:         tmpDir,
+		timeout:     timeout,
+		limits:      e.runLimits(lang, timeout, job.MemoryLimitMB(lang)),
+		outputLimit: outputLimit,
+		env:         vars.Expand(lang.RunEnv),
+		args:        vars.Expand(lang.Run),
+	}, streams)
+	if err == nil && job.Unit {
+		result["report"] = ReadReport(tmpDir, lang, outputLimit)
 	}
+	return result, err
+}
 
-	if err != nil {
-		return map[string]interface{}{
-			"output":   stdout.String(),
-			"error":    stderr.String(),
-			"exitCode": 1,
-		}, nil
+// runLimits - лимиты программы в песочнице с поправками языка и задачи.
+// Лимит CPU не больше лимита времени, округленного вверх до секунды
+func (e *LocalExecutor) runLimits(lang *languages.Language, timeout time.Duration, memoryMB int) sandbox.Limits {
+	if e.sandbox == nil {
+		return sandbox.Limits{}
+	}
+	limits := e.sandbox.DefaultLimits()
+	if lang.UnlimitedAddressSpace {
+		limits.AddressMB = 0
+	}
+	if memoryMB > 0 {
+		limits.MemoryMB = memoryMB
 	}
+	if cpu := int(math.Ceil(timeout.Seconds())); cpu > 0 && (limits.CPUSeconds == 0 || cpu < limits.CPUSeconds) {
+		limits.CPUSeconds = cpu
+	}
+	return limits
+}
 
-	return map[string]interface{}{
-		"output":   stdout.String(),
-		"error":    "",
-		"exitCode": 0,
-	}, nil
+// process - команда, запускаемая напрямую или через песочницу
+type process struct {
+	cmd *exec.Cmd
+	run *sandbox.Run
 }
 
-func (e *LocalExecutor) executeCpp(code string) (map[string]interface{}, error) {
-	// Реальное выполнение C++ (оно работает)
-	tmpDir, err := os.MkdirTemp("", "cpp_exec_*")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %v", err)
+func (e *LocalExecutor) command(ctx context.Context, dir string, limits sandbox.Limits, env []string, name string, args ...string) (*process, error) {
+	if e.sandbox == nil {
+		return &process{cmd: directCommand(ctx, dir, env, name, args...)}, nil
 	}
-	defer os.RemoveAll(tmpDir)
 
-	sourceFile := filepath.Join(tmpDir, "main.cpp")
-	if err := os.WriteFile(sourceFile, []byte(code), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write code: %v", err)
+	// В песочнице программа - init своего PID namespace, с ней умирают все потомки
+	run, err := e.sandbox.Command(ctx, dir, limits, env, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: %v", err)
 	}
+	setProcessGroup(run.Cmd)
+	return &process{cmd: run.Cmd, run: run}, nil
+}
 
-	executable := filepath.Join(tmpDir, "main")
-	compileCmd := exec.Command("g++", "-o", executable, sourceFile)
-	var compileStderr bytes.Buffer
-	compileCmd.Stderr = &compileStderr
+func (p *process) stats() sandbox.Stats {
+	if p.run == nil {
+		return sandbox.Stats{}
+	}
+	return p.run.Stats()
+}
 
-	if err := compileCmd.Run(); err != nil {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Compilation failed: " + compileStderr.String(),
-			"exitCode": 1,
-		}, nil
+func (p *process) close() {
+	if p.run != nil {
+		p.run.Close()
 	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// directCommand - команда без песочницы от пользователя сервера.
+// Окружение сервера (DATABASE_URL, JUDGE_TOKEN и прочее) не передаем,
+// как и в песочницу: HOME оставляем для кэшей компиляторов
+func directCommand(ctx context.Context, dir string, env []string, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + os.Getenv("HOME"),
+		"TMPDIR=" + os.TempDir(),
+		"LANG=C.UTF-8",
+	}, env...)
+	setProcessGroup(cmd)
+	return cmd
+}
 
-	cmd := exec.CommandContext(ctx, executable)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// build собирает дерево задания в dir или берет готовую сборку из кэша.
+// Возвращает результат только при ошибке компиляции
+func (e *LocalExecutor) build(ctx context.Context, dir string, lang *languages.Language, vars languages.Vars, tree []utils.TarFile) (map[string]interface{}, error) {
+	if e.compileCache == nil {
+		return e.compile(ctx, dir, lang, vars)
+	}
 
-	err = cmd.Run()
+	key := CompileKey(lang, e.toolchain(ctx, lang), lang.Compile, tree)
+	if artifacts, ok := e.compileCache.Get(key); ok {
+		log.Printf("♻️ Compile cache hit for %s (%s)", lang.ID, key[:12])
+		return nil, WriteTree(dir, artifacts)
+	}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (30 seconds exceeded)",
-			"exitCode": 1,
-		}, nil
+	if result, err := e.compile(ctx, dir, lang, vars); result != nil || err != nil {
+		return result, err
 	}
 
+	// Кэш - только ускорение: если сборку не сохранить, запуск все равно идет
+	artifacts, err := Artifacts(dir, tree, MaxArtifactsSize)
+	if err == nil {
+		err = e.compileCache.Put(key, artifacts)
+	}
 	if err != nil {
-		return map[string]interface{}{
-			"output":   stdout.String(),
-			"error":    stderr.String(),
-			"exitCode": 1,
-		}, nil
+		log.Printf("⚠️ Build of %s is not cached: %v", lang.ID, err)
 	}
+	return nil, nil
+}
 
-	return map[string]interface{}{
-		"output":   stdout.String(),
-		"error":    "",
-		"exitCode": 0,
-	}, nil
+// toolchain - отпечаток компилятора для ключа кэша сборки: путь, размер и
+// время изменения программы сборки и вывод version_cmd. Версия
+// спрашивается заново, только если программа сборки изменилась
+func (e *LocalExecutor) toolchain(ctx context.Context, lang *languages.Language) string {
+	stamp := lang.ID
+	if name := lang.Compile[0]; !strings.HasPrefix(name, "{") {
+		if path, err := exec.LookPath(name); err == nil {
+			if info, err := os.Stat(path); err == nil {
+				stamp = fmt.Sprintf("%s %s %d %d", lang.ID, path, info.Size(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	if id, ok := e.toolchains.Load(stamp); ok {
+		return id.(string)
+	}
+	id := stamp + "\n" + languages.ProbeVersion(ctx, lang.VersionCmd)
+	e.toolchains.Store(stamp, id)
+	return id
 }
 
-func (e *LocalExecutor) executeJava(code string) (map[string]interface{}, error) {
-	log.Printf("☕ Executing Java code for real")
+// compile запускает компилятор языка. Возвращает результат только при ошибке компиляции.
+// Компиляторы с compile_outside_sandbox запускаются без песочницы, чтобы делить
+// общий кэш: у каждого запуска в песочнице свой UID
+func (e *LocalExecutor) compile(ctx context.Context, dir string, lang *languages.Language, vars languages.Vars) (map[string]interface{}, error) {
+	ctx, cancel := WithDefaultTimeout(ctx, lang.CompileTimeout())
+	defer cancel()
 
-	// Создаем временную директорию
-	tmpDir, err := os.MkdirTemp("", "java_exec_*")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %v", err)
+	args := vars.Expand(lang.Compile)
+	env := vars.Expand(lang.CompileEnv)
+
+	if lang.CompileOutsideSandbox {
+		return runCompiler(ctx, directCommand(ctx, dir, env, args[0], args[1:]...))
 	}
-	defer os.RemoveAll(tmpDir)
 
-	// Записываем код в файл
-	sourceFile := filepath.Join(tmpDir, "Main.java")
-	if err := os.WriteFile(sourceFile, []byte(code), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write code: %v", err)
+	proc, err := e.command(ctx, dir, compileLimits, env, args[0], args[1:]...)
+	if err != nil {
+		return nil, err
 	}
+	defer proc.close()
 
-	// Компилируем
-	compileCmd := exec.Command("javac", sourceFile)
-	var compileStderr bytes.Buffer
-	compileCmd.Stderr = &compileStderr
+	return runCompiler(ctx, proc.cmd)
+}
 
-	if err := compileCmd.Run(); err != nil {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Compilation failed: " + compileStderr.String(),
-			"exitCode": 1,
-		}, nil
+// runCompiler выполняет подготовленную команду компилятора
+func runCompiler(ctx context.Context, cmd *exec.Cmd) (map[string]interface{}, error) {
+	var compileStderr bytes.Buffer
+	cmd.Stdout = &compileStderr
+	cmd.Stderr = &compileStderr
+
+	started := time.Now()
+	err := cmd.Run()
+	killProcessGroup(cmd)
+
+	switch {
+	case ctx.Err() == context.Canceled:
+		return executionResult(models.VerdictCancelled, TimeoutMessage(ctx, started), 1), nil
+	case ctx.Err() != nil:
+		return executionResult(models.VerdictCompilationError, "Compilation failed: "+TimeoutMessage(ctx, started), 1), nil
+	case err != nil:
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("failed to run compiler: %v", err)
+		}
+		return executionResult(models.VerdictCompilationError, "Compilation failed: "+compileStderr.String(), 1), nil
 	}
+	return nil, nil
+}
 
-	// Выполняем
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// program - что и с какими ограничениями запустить
+type program struct {
+	dir         string
+	timeout     time.Duration
+	limits      sandbox.Limits
+	outputLimit int64
+	env         []string // дополняет окружение
+	args        []string
+}
+
+// runProgram запускает программу с таймаутом, подключая её к streams
+func (e *LocalExecutor) runProgram(ctx context.Context, prog program, streams Streams) (map[string]interface{}, error) {
+	ctx, cancel := WithDefaultTimeout(ctx, prog.timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "java", "-cp", tmpDir, "Main")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	limits := prog.limits
+	name := prog.args[0]
+	proc, err := e.command(ctx, prog.dir, limits, prog.env, name, prog.args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	defer proc.close()
+
+	// Превышение лимита вывода сразу останавливает программу
+	limiter := NewOutputLimiter(prog.outputLimit, cancel)
+
+	cmd := proc.cmd
+	cmd.Stdout = limiter.Wrap(streams.Stdout)
+	cmd.Stderr = limiter.Wrap(streams.Stderr)
+	// Потомки могут держать stdout открытым после выхода программы
+	cmd.WaitDelay = 500 * time.Millisecond
+
+	// Stdin копируем сами: exec.Cmd ждал бы конца ввода даже после выхода программы,
+	// а в интерактивной сессии ввод заканчивается только с отключением клиента
+	var stdinPipe io.WriteCloser
+	if streams.Stdin != nil {
+		pipe, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open stdin: %v", err)
+		}
+		stdinPipe = pipe
+	}
+
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %v", name, err)
+	}
+
+	if stdinPipe != nil {
+		go func() {
+			io.Copy(stdinPipe, streams.Stdin)
+			stdinPipe.Close()
+		}()
+	}
+
+	err = cmd.Wait()
+	wallTime := time.Since(started)
+	killProcessGroup(cmd)
+	if errors.Is(err, exec.ErrWaitDelay) && cmd.ProcessState.Success() {
+		// Программа завершилась, а stdout держал оставленный ею потомок
+		err = nil
+	}
 
-	err = cmd.Run()
+	sandboxStats := proc.stats()
+	result := programResult(ctx, err, cmd.ProcessState, sandboxStats, limits, limiter, prog.outputLimit, started)
+	result["stats"] = runStats(cmd.ProcessState, sandboxStats, wallTime)
+	return result, nil
+}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		return map[string]interface{}{
-			"output":   "",
-			"error":    "Execution timeout (30 seconds exceeded)",
-			"exitCode": 1,
-		}, nil
+// programResult - вердикт по завершившейся программе
+func programResult(ctx context.Context, err error, state *os.ProcessState, stats sandbox.Stats, limits sandbox.Limits, limiter *OutputLimiter, outputLimit int64, started time.Time) map[string]interface{} {
+	switch {
+	case limiter.Exceeded():
+		return executionResult(models.VerdictOutputLimit, fmt.Sprintf("Output limit exceeded (%d bytes)", outputLimit), 1)
+	case ctx.Err() == context.Canceled:
+		return executionResult(models.VerdictCancelled, TimeoutMessage(ctx, started), 1)
+	case ctx.Err() != nil:
+		return executionResult(models.VerdictTimeLimit, TimeoutMessage(ctx, started), 1)
+	case stats.OOMKilled:
+		return executionResult(models.VerdictMemoryLimit, "Memory limit exceeded", 1)
+	case sandbox.CPULimitExceeded(state, limits):
+		return executionResult(models.VerdictTimeLimit, "CPU time limit exceeded", 1)
 	}
 
 	if err != nil {
-		return map[string]interface{}{
-			"output":   stdout.String(),
-			"error":    stderr.String(),
-			"exitCode": 1,
-		}, nil
+		exitCode := 1
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			exitCode = exitErr.ExitCode()
+		}
+		return executionResult(models.VerdictRuntimeError, "", exitCode)
 	}
 
+	return executionResult(models.VerdictOK, "", 0)
+}
+
+// runStats собирает замеры запуска. Память берем из cgroup, если песочница
+// ее считает. ru_maxrss переживает exec, поэтому без cgroup пик не меньше
+// памяти процесса, который запускал программу (сервера или помощника песочницы)
+func runStats(state *os.ProcessState, sandboxStats sandbox.Stats, wallTime time.Duration) models.ExecutionStats {
+	stats := models.ExecutionStats{WallTimeMs: wallTime.Milliseconds()}
+	if state != nil {
+		stats.CPUTimeMs = (state.UserTime() + state.SystemTime()).Milliseconds()
+	}
+	if sandboxStats.PeakMemoryBytes > 0 {
+		stats.PeakMemoryKB = sandboxStats.PeakMemoryBytes / 1024
+	} else {
+		stats.PeakMemoryKB = maxRSSKB(state)
+	}
+	return stats
+}
+
+// executionResult собирает результат в формате Execute.
+// Замеры заполняет runProgram, до запуска программы они нулевые
+func executionResult(verdict, errorMsg string, exitCode int) map[string]interface{} {
 	return map[string]interface{}{
-		"output":   stdout.String(),
-		"error":    "",
-		"exitCode": 0,
-	}, nil
+		"verdict":  verdict,
+		"error":    errorMsg,
+		"exitCode": exitCode,
+		"stats":    models.ExecutionStats{},
+	}
 }
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultOutputLimit - сколько вывода (stdout и stderr вместе) разрешено программе
+const DefaultOutputLimit = 1 << 20 // 1MB
+
+// ErrOutputLimit - программа вывела больше разрешенного
+var ErrOutputLimit = errors.New("output limit exceeded")
+
+// OutputLimiter считает общий объем stdout и stderr программы.
+// После лимита запись обрывается и вызывается onExceed (обычно отмена запуска)
+type OutputLimiter struct {
+	mu       sync.Mutex
+	limit    int64
+	written  int64
+	exceeded bool
+	onExceed func()
+}
+
+func NewOutputLimiter(limit int64, onExceed func()) *OutputLimiter {
+	return &OutputLimiter{limit: limit, onExceed: onExceed}
+}
+
+// Wrap возвращает writer, пишущий в w с учетом общего лимита
+func (l *OutputLimiter) Wrap(w io.Writer) io.Writer {
+	if w == nil {
+		w = io.Discard
+	}
+	return &limitedWriter{limiter: l, w: w}
+}
+
+// Exceeded - был ли превышен лимит
+func (l *OutputLimiter) Exceeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exceeded
+}
+
+type limitedWriter struct {
+	limiter *OutputLimiter
+	w       io.Writer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	l := w.limiter
+
+	l.mu.Lock()
+	if l.exceeded {
+		l.mu.Unlock()
+		return 0, ErrOutputLimit
+	}
+	if remaining := l.limit - l.written; int64(len(p)) > remaining {
+		l.exceeded = true
+		p = p[:remaining]
+	}
+	l.written += int64(len(p))
+	exceeded := l.exceeded
+	l.mu.Unlock()
+
+	n := 0
+	if len(p) > 0 {
+		var err error
+		if n, err = w.w.Write(p); err != nil {
+			return n, err
+		}
+	}
+
+	if exceeded {
+		if l.onExceed != nil {
+			l.onExceed()
+		}
+		return n, ErrOutputLimit
+	}
+	return n, nil
+}
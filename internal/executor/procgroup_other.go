@@ -0,0 +1,15 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// На платформах без групп процессов убиваем только сам процесс
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil || cmd.ProcessState != nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
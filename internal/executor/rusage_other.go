@@ -0,0 +1,10 @@
+//go:build !unix
+
+package executor
+
+import "os"
+
+// maxRSSKB - на этой платформе rusage недоступен
+func maxRSSKB(state *os.ProcessState) int64 {
+	return 0
+}
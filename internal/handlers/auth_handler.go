@@ -5,10 +5,25 @@ import (
 	"backend/internal/services"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 var authService = services.NewAuthService()
 
+// currentUser - пользователь по заголовку Authorization: Bearer <token>.
+// nil - запрос анонимный или токен недействителен
+func currentUser(r *http.Request) *models.User {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	user, err := authService.ValidateToken(token)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
 // GuestAuthHandler обработчик гостевого доступа
 func GuestAuthHandler(w http.ResponseWriter, r *http.Request) {
 	// Разрешаем и GET и POST для простоты тестирования
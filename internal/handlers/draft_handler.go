@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"backend/internal/drafts"
+	"backend/internal/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Черновики кода ученика: /api/me/drafts/{taskId}/{language}.
+// Задача может быть и не из библиотеки - страница задачи принимает любой id
+
+// DraftRequest - тело PUT: код редактора и версия, от которой шла правка
+// (0 - черновика еще нет)
+type DraftRequest struct {
+	Code    string              `json:"code"`
+	Files   []models.SourceFile `json:"files,omitempty"`
+	Version int                 `json:"version"`
+}
+
+// DraftConflict - ответ 409: черновик уже сохранили с другой версией
+type DraftConflict struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Draft   *models.Draft `json:"draft,omitempty"` // текущий черновик
+}
+
+// DraftHandler - GET и PUT /api/me/drafts/{taskId}/{language},
+// GET /api/me/drafts/{taskId}/{language}/revisions
+func DraftHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if user == nil {
+		http.Error(w, `{"success": false, "message": "Authorization required"}`, http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/me/drafts/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "revisions") {
+		http.Error(w, `{"success": false, "message": "Use /api/me/drafts/{taskId}/{language}"}`, http.StatusNotFound)
+		return
+	}
+	taskID := parts[0]
+	if taskID == "" || len(taskID) > 36 {
+		http.Error(w, `{"success": false, "message": "Invalid task id"}`, http.StatusBadRequest)
+		return
+	}
+	lang, ok := languageRegistry.Lookup(parts[1])
+	if !ok {
+		http.Error(w, `{"success": false, "message": "Unsupported language"}`, http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 3 && r.Method == "GET":
+		revisions, err := draftStore.Revisions(r.Context(), user.ID, taskID, lang.ID)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			http.Error(w, `{"success": false, "message": "Failed to load draft revisions"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"revisions": revisions})
+	case len(parts) == 2 && r.Method == "GET":
+		draft, err := draftStore.Get(r.Context(), user.ID, taskID, lang.ID)
+		if errors.Is(err, drafts.ErrNotFound) {
+			http.Error(w, `{"success": false, "message": "Draft not found"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ %v", err)
+			http.Error(w, `{"success": false, "message": "Failed to load draft"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(draft)
+	case len(parts) == 2 && r.Method == "PUT":
+		saveDraft(w, r, user, taskID, lang.ID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func saveDraft(w http.ResponseWriter, r *http.Request, user *models.User, taskID, language string) {
+	var req DraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Version < 0 {
+		http.Error(w, `{"success": false, "message": "Invalid version"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Черновик ограничен так же, как решение
+	job := newJob("", req.Code, language, req.Files)
+	if err := job.Validate(); err != nil {
+		writeFilesError(w, err)
+		return
+	}
+
+	draft := models.Draft{TaskID: taskID, Language: language, Code: req.Code, Files: req.Files}
+	saved, err := draftStore.Save(r.Context(), user.ID, draft, req.Version)
+	if errors.Is(err, drafts.ErrConflict) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DraftConflict{
+			Success: false,
+			Message: "Черновик изменен в другой вкладке",
+			Draft:   saved,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ %v", err)
+		http.Error(w, `{"success": false, "message": "Failed to save draft"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// TaskDraft - черновик ученика для страницы задачи, nil - запрос
+// анонимный или черновика нет
+func TaskDraft(r *http.Request, taskID, language string) *models.Draft {
+	user := currentUser(r)
+	if user == nil {
+		return nil
+	}
+	draft, err := draftStore.Get(r.Context(), user.ID, taskID, language)
+	if err != nil {
+		if !errors.Is(err, drafts.ErrNotFound) {
+			log.Printf("⚠️ %v", err)
+		}
+		return nil
+	}
+	return draft
+}
@@ -1,14 +1,27 @@
 package handlers
 
 import (
+	"backend/internal/checker"
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/drafts"
 	"backend/internal/executor"
+	"backend/internal/judge"
+	"backend/internal/languages"
+	"backend/internal/leaderboard"
 	"backend/internal/models"
+	"backend/internal/progress"
 	"backend/internal/services"
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,24 +29,189 @@ import (
 // Короче, тут выбираем стратегию выполнения, либо Docker либо Локально
 // потом преобразование результатов в единый формат ответа
 
-var dockerService *services.DockerService // Изоляция
-var localExecutor *executor.LocalExecutor // Быстро
+var dockerService *services.DockerService   // Изоляция
+var languageRegistry *languages.Registry    // Какие языки умеем запускать
+var outputLimit int64                       // Лимит вывода по умолчанию, байт
+var executionStore *database.ExecutionStore // История запусков, nil - без базы
+var progressStore progress.Store            // Прогресс учеников
+var draftStore drafts.Store                 // Черновики кода
+var leaderboards *leaderboard.Leaderboard   // Таблицы лидеров
+var jobRunner *judge.Runner                 // Выполнение заданий в этом процессе
+var judgeQueue *judge.Queue                 // Очередь для воркеров, nil - без воркеров
+var judgeHandler http.HandlerFunc           // API очереди для воркеров
 
 func init() {
+	cfg := config.Load()
+
 	var err error
-	dockerService, err = services.NewDockerService()
+	languageRegistry, err = languages.Load(cfg.LanguagesFile)
 	if err != nil {
-		log.Printf("Warning: Docker service not available: %v", err)
-		log.Println("Running in local execution mode")
-	} else {
-		log.Println("✅ Docker service initialized successfully")
+		log.Fatalf("❌ Failed to load languages: %v", err)
+	}
+	log.Printf("📚 Languages loaded: %s", strings.Join(languageRegistry.IDs(), ", "))
+
+	runner, err := judge.NewRunner(cfg, languageRegistry)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	dockerService = runner.Docker
+	outputLimit = cfg.OutputLimit
+	jobRunner = runner
+	if cfg.Judge.Mode == "remote" {
+		judgeQueue = startJudgeQueue(cfg, runner)
+	}
+
+	progressStore = progress.NewMemoryStore()
+	draftStore = drafts.NewMemoryStore()
+	var leaderboardSettings leaderboard.SettingsStore = leaderboard.NewMemorySettingsStore()
+	if cfg.DatabaseEnabled {
+		if db := openDatabase(cfg); db != nil {
+			executionStore = database.NewExecutionStore(db)
+			progressStore = database.NewProgressStore(db)
+			draftStore = database.NewDraftStore(db)
+			leaderboardSettings = database.NewLeaderboardSettingsStore(db)
+		}
+	}
+	leaderboards = loadLeaderboards(leaderboardSettings)
+
+	// Проверяем, какие языки реально можно запустить по политике исполнителя,
+	// и перепроверяем по таймеру
+	var images languages.ImageChecker
+	if dockerService != nil {
+		images = dockerService
+	}
+	toolchains = languages.NewProber(languageRegistry, images, runner)
+	runner.SetToolchains(toolchains)
+	toolchains.Probe(context.Background())
+	go toolchains.Run(context.Background(), cfg.ToolchainProbeInterval)
+
+	// Образы проверяем при старте, скачивание не задерживает запуск сервера
+	if dockerService != nil {
+		go func() {
+			dockerService.EnsureImages(context.Background(), languageRegistry.Images(), cfg.Docker.PullMissing)
+			if cfg.Docker.PullMissing {
+				// Скачанные образы сразу становятся доступны
+				toolchains.Probe(context.Background())
+			}
+		}()
+	}
+	// Docker или локально - решает политика исполнителя (EXECUTOR_POLICY),
+	// локальный запуск только там, где она его разрешает
+}
+
+// startJudgeQueue запускает очередь для воркеров и встроенных воркеров.
+// Потоковые запуски и интерактивные сессии по-прежнему идут в процессе API
+func startJudgeQueue(cfg *config.Config, runner *judge.Runner) *judge.Queue {
+	if cfg.Judge.Token == "" && cfg.Judge.LocalWorkers == 0 {
+		log.Fatalf("❌ JUDGE_MODE=remote needs JUDGE_TOKEN for workers or JUDGE_LOCAL_WORKERS")
+	}
+	queue := judge.NewQueue(judge.QueueOptions{
+		WorkerTimeout: cfg.Judge.WorkerTimeout,
+		LeaseTimeout:  cfg.Judge.LeaseTimeout,
+		LeaseWait:     cfg.Judge.LeaseWait,
+		MaxAttempts:   cfg.Judge.MaxAttempts,
+		Languages:     languageRegistry,
+	})
+	go queue.Run(context.Background())
+	judgeHandler = judge.Handler(queue, cfg.Judge.Token)
+
+	for i := 0; i < cfg.Judge.LocalWorkers; i++ {
+		info := judge.WorkerInfo{
+			Name:   fmt.Sprintf("%s/local-%d", cfg.Judge.WorkerName, i+1),
+			Slots:  cfg.Judge.Slots,
+			Docker: runner.Docker != nil,
+		}
+		worker := judge.NewWorker(judge.LocalTransport{Queue: queue}, info, cfg.Judge.HeartbeatInterval, runner.Run)
+		go worker.Run(context.Background())
+	}
+	log.Printf("📮 Judge queue started, %d local workers", cfg.Judge.LocalWorkers)
+	return queue
+}
+
+// openDatabase подключает базу для истории запусков и прогресса.
+// Без базы сервер работает: запуски не сохраняются, прогресс живет в памяти
+func openDatabase(cfg *config.Config) *sql.DB {
+	db, err := database.NewPostgresConnection(database.Config(cfg.Database))
+	if err != nil {
+		log.Printf("Warning: Database not available, executions are not saved: %v", err)
+		return nil
+	}
+	if err := database.RunMigrations(db); err != nil {
+		log.Printf("Warning: Database migrations failed, executions are not saved: %v", err)
+		return nil
 	}
+	return db
+}
+
+// recordExecution сохраняет запуск в фоне, чтобы не задерживать ответ
+func recordExecution(r *http.Request, job executor.Job, taskID string, response models.ExecutionResponse) {
+	result := newExecutionResult(r, job, taskID, models.KindRun)
+	result.Output = response.Output
+	result.Success = response.Success
+	result.Verdict = response.Verdict
+	result.ImageDigest = response.ImageDigest
+	result.Backend = response.Backend
+	if response.Stats != nil {
+		result.Stats = *response.Stats
+	}
+	saveExecution(result)
+}
+
+// recordCheck сохраняет проверку решения в историю посылок и учитывает
+// ее в прогрессе ученика
+func recordCheck(r *http.Request, job executor.Job, taskID string, response models.CheckResponse) {
+	recordProgress(r, taskID, response)
+
+	result := newExecutionResult(r, job, taskID, models.KindCheck)
+	result.Output = response.Output
+	result.Success = response.Success
+	result.Verdict = response.Verdict
+	result.Message = response.Message
+	result.Tests = response.Tests
+	result.ImageDigest = response.ImageDigest
+	result.Backend = response.Backend
+	if response.Stats != nil {
+		result.Stats = *response.Stats
+	}
+	saveExecution(result)
+}
+
+// newExecutionResult - запись истории с кодом и файлами решения
+func newExecutionResult(r *http.Request, job executor.Job, taskID, kind string) *models.ExecutionResult {
+	result := &models.ExecutionResult{
+		TaskID:   taskID,
+		Code:     job.Code,
+		Language: job.Language,
+		Kind:     kind,
+	}
+	if lang, ok := languageRegistry.Lookup(job.Language); ok {
+		result.Language = lang.ID
+	}
+	if user := currentUser(r); user != nil {
+		result.UserID = user.ID
+	}
+	for path, content := range job.Files {
+		result.Files = append(result.Files, models.SourceFile{Path: path, Content: content})
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+	return result
+}
 
-	// Инициализируем локальный исполнитель
-	localExecutor = executor.NewLocalExecutor()
-	// Если не получается создать Docker сервис для изолированного выполнения, то
-	// Переходим в локальный режим
-	// Локалка создаётся всегда
+// saveExecution пишет запись в базу в фоне. Без базы ничего не делает
+func saveExecution(result *models.ExecutionResult) {
+	if executionStore == nil {
+		return
+	}
+	go func() {
+		if err := executionStore.Save(context.Background(), result); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}()
+}
+
+// Languages - реестр языков, с которым работают исполнители
+func Languages() *languages.Registry {
+	return languageRegistry
 }
 
 func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,89 +228,64 @@ func ExecuteHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("🔧 Executing code for language: %s", req.Language)
 
-	var response models.ExecutionResponse
-
-	// Пробуем Docker сначала
-	if dockerService != nil {
-		log.Println("🐳 Attempting Docker execution...")
-		result, err := dockerService.ExecuteCode(req.Code, req.Language)
-		if err != nil {
-			log.Printf("❌ Docker execution failed: %v", err)
-			log.Println("🔄 Falling back to local execution...")
-			response = executeCodeWithLocalExecutor(req.Code, req.Language)
-		} else {
-			log.Printf("✅ Docker execution successful, output: %s", result.Output)
-			response = models.ExecutionResponse{
-				Success: result.Success,
-				Message: "Code executed successfully via Docker",
-				Output:  result.Output,
-			}
-			if !result.Success {
-				response.Message = "Code execution failed in Docker"
-			}
-		}
-	} else {
-		log.Println("🔄 Docker not available, using local execution...")
-		response = executeCodeWithLocalExecutor(req.Code, req.Language)
+	if message, status := checkLanguage(req.Language); message != "" {
+		log.Printf("❌ %s", message)
+		writeLanguageError(w, message, status)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// Новая функция использующая LocalExecutor для всех языков
-func executeCodeWithLocalExecutor(code, language string) models.ExecutionResponse {
-	log.Printf("🔧 Executing %s code with local executor", language)
-
-	// УДАЛИ ЭТУ ПРОВЕРКУ - она блокирует Java!
-	// if language == "java" {
-	//     // Проверяем установлен ли Java компилятор
-	//     if _, err := exec.LookPath("javac"); err != nil {
-	//         return models.ExecutionResponse{
-	//             Success: false,
-	//             Message: "Java execution is not available. Please install Java JDK.",
-	//             Output:  "",
-	//         }
-	//     }
-	// }
+	job := newJob(req.TaskID, req.Code, req.Language, req.Files)
+	if err := job.Validate(); err != nil {
+		log.Printf("❌ %v", err)
+		writeFilesError(w, err)
+		return
+	}
 
-	result, err := localExecutor.Execute(code, language)
+	response := executeJob(r.Context(), job)
 
-	if err != nil {
-		log.Printf("❌ Local execution error: %v", err)
-		return models.ExecutionResponse{
-			Success: false,
-			Message: "Execution failed: " + err.Error(),
-			Output:  "",
-		}
+	if r.Context().Err() != nil {
+		// Клиент ушел, отвечать некому
+		log.Printf("🔌 Client disconnected, execution cancelled")
+		return
 	}
+	recordExecution(r, job, req.TaskID, response)
 
-	// Преобразуем результат из LocalExecutor в models.ExecutionResponse
-	exitCode := result["exitCode"].(int)
-	output := result["output"].(string)
-	errorMsg := result["error"].(string)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	success := exitCode == 0
-	finalOutput := output
-	if errorMsg != "" {
-		finalOutput = errorMsg
-		if output != "" {
-			finalOutput = output + "\n" + errorMsg
-		}
+// executeJob отдает задание воркерам, если настроена очередь, иначе
+// выполняет его в этом процессе: в Docker, а если он недоступен - локально
+func executeJob(ctx context.Context, job executor.Job) models.ExecutionResponse {
+	if judgeQueue == nil {
+		return jobRunner.Run(ctx, job)
 	}
 
-	message := "Код выполнен успешно (локально)"
-	if !success {
-		message = "Ошибка выполнения кода"
+	log.Printf("📮 Sending %s job to judge workers", job.Language)
+	response, err := judgeQueue.Submit(ctx, job)
+	switch {
+	case err == nil:
+		return response
+	case errors.Is(err, judge.ErrNoWorkers):
+		log.Printf("❌ %v", err)
+		return models.ExecutionResponse{Success: false, Message: "Нет доступных проверяющих серверов"}
+	default:
+		return models.ExecutionResponse{Success: false, Message: "Execution cancelled", Verdict: models.VerdictCancelled}
 	}
+}
 
-	log.Printf("✅ Local execution completed, success: %t, output length: %d", success, len(finalOutput))
+// ExecutorStatus - политика исполнителя и состояние Docker для health check
+func ExecutorStatus() judge.RunnerStatus {
+	return jobRunner.Status()
+}
 
-	return models.ExecutionResponse{
-		Success: success,
-		Message: message,
-		Output:  finalOutput,
+// JudgeHandler - API очереди для воркеров (/api/judge/...). Без очереди - 404
+func JudgeHandler(w http.ResponseWriter, r *http.Request) {
+	if judgeQueue == nil {
+		http.NotFound(w, r)
+		return
 	}
+	judgeHandler(w, r)
 }
 
 // CheckHandler - проверка решений задач
@@ -197,49 +350,61 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 	language, _ := rawReq["language"].(string)
 	code, _ := rawReq["code"].(string)
 
-	log.Printf("🔍 Parsed request: task_id=%s, language=%s, code_length=%d",
-		taskID, language, len(code))
+	// Файлы решения разбираем отдельно в типизированную структуру
+	var filesReq models.CheckRequest
+	if err := json.Unmarshal(bodyBytes, &filesReq); err != nil {
+		log.Printf("❌ Invalid files in request: %v", err)
+		http.Error(w, `{"success": false, "message": "Invalid files format"}`, http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("🔍 Parsed request: task_id=%s, language=%s, code_length=%d, files=%d",
+		taskID, language, len(code), len(filesReq.Files))
+
+	if message, status := checkLanguage(language); message != "" {
+		log.Printf("❌ %s", message)
+		writeLanguageError(w, message, status)
+		return
+	}
 
 	// Выполнение кода
 	log.Printf("🚀 Starting code execution for task %s", taskID)
-	var executionResult models.ExecutionResponse
+	job := newJob(taskID, code, language, filesReq.Files)
+	if err := job.Validate(); err != nil {
+		log.Printf("❌ %v", err)
+		writeFilesError(w, err)
+		return
+	}
 
-	if dockerService != nil {
-		log.Println("🐳 Attempting Docker execution...")
-		result, err := dockerService.ExecuteCode(code, language)
-		if err != nil {
-			log.Printf("❌ Docker execution failed: %v", err)
-			log.Println("🔄 Falling back to local execution...")
-			executionResult = executeCodeWithLocalExecutor(code, language)
+	// Задача на функцию: каждый тест - отдельный запуск с обвязкой.
+	// Задача с юнит-тестами: один запуск под тестовым фреймворком
+	if task, ok := findTask(taskID); ok && (task.Function != nil || len(task.UnitTests) > 0) {
+		var response models.CheckResponse
+		if len(task.UnitTests) > 0 {
+			response = checkUnitTests(r.Context(), task, job)
 		} else {
-			log.Printf("✅ Docker execution successful")
-			executionResult = models.ExecutionResponse{
-				Success: result.Success,
-				Output:  result.Output,
-			}
+			response = checkFunction(r.Context(), task, job)
 		}
-	} else {
-		log.Println("🔄 Docker not available, using local execution...")
-		executionResult = executeCodeWithLocalExecutor(code, language)
+		if r.Context().Err() != nil {
+			log.Printf("🔌 Client disconnected, check cancelled")
+			return
+		}
+		recordCheck(r, job, taskID, response)
+		log.Printf("✅ Check completed: passed=%t, message=%s", response.Passed, response.Message)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
 	}
 
-	log.Printf("📊 Execution result: success=%t, output_length=%d",
-		executionResult.Success, len(executionResult.Output))
-
-	// Проверка решения
+	// Задача на ввод-вывод: запуск на каждом тесте с его вводом в stdin
 	log.Printf("🧪 Checking solution against test cases")
-	checkResult := checkSolution(taskID, executionResult.Output, language)
-
-	// Формирование ответа
-	response := models.CheckResponse{
-		Success:  executionResult.Success && checkResult.Passed,
-		Passed:   checkResult.Passed,
-		Output:   executionResult.Output,
-		Expected: checkResult.Expected,
-		Actual:   checkResult.Actual,
-		Message:  checkResult.Message,
+	response := checkTests(r.Context(), taskID, job)
+	if r.Context().Err() != nil {
+		log.Printf("🔌 Client disconnected, check cancelled")
+		return
 	}
 
+	recordCheck(r, job, taskID, response)
 	log.Printf("✅ Check completed: passed=%t, message=%s", response.Passed, response.Message)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -252,57 +417,97 @@ func CheckHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("📤 Response sent successfully")
 }
 
-// checkSolution - проверяет вывод кода против ожидаемого результата
-func checkSolution(taskID, actualOutput, language string) models.CheckResult {
-	log.Printf("🔎 Checking solution for task=%s, language=%s", taskID, language)
-
-	// Простая заглушка - в реальности брать из БД или из models.Task
-	testCases := map[string]map[string]string{
-		"1": {
-			"python":     "Hello World",
-			"javascript": "Hello World",
-			"cpp":        "Hello World",
-			"java":       "Hello World",
-		},
-		"hello_world": {
-			"python":     "Hello World",
-			"javascript": "Hello World",
-			"cpp":        "Hello World",
-			"java":       "Hello World",
-		},
-	}
-
-	expected := "Hello World"
-	if cases, exists := testCases[taskID]; exists {
-		if exp, exists := cases[language]; exists {
-			expected = exp
+// judgeOutput сравнивает вывод с ожидаемым встроенным режимом или программой-чекером
+func judgeOutput(ctx context.Context, cfg models.CheckerConfig, input, expected, actual string) (checker.Result, error) {
+	if cfg.Mode != checker.ModeCustom {
+		return checker.Compare(cfg, expected, actual)
+	}
+	log.Printf("🧑‍⚖️ Running custom %s checker", cfg.Language)
+	run := executeJob(ctx, checker.CustomJob(cfg, input, expected, actual))
+	return checker.CustomResult(run.Verdict, run.Output)
+}
+
+// checkTests запускает решение на каждом тесте задачи, подавая ввод теста
+// в stdin, и проверяет вывод чекером задачи. Останавливается на первом
+// непройденном тесте. Для неизвестной задачи - старое поведение: один
+// запуск без ввода, ждем Hello World
+func checkTests(ctx context.Context, taskID string, job executor.Job) models.CheckResponse {
+	log.Printf("🔎 Checking solution for task=%s, language=%s", taskID, job.Language)
+
+	tests := []models.Test{{ExpectedOutput: "Hello World"}}
+	var cfg models.CheckerConfig
+	if task, ok := findTask(taskID); ok {
+		cfg = task.Checker
+		if len(task.Tests) > 0 {
+			tests = task.Tests
 		}
 	}
 
-	// Улучшенная проверка - ищем ожидаемую строку в выводе
-	actualTrimmed := strings.TrimSpace(actualOutput)
-	passed := false
+	var stats models.ExecutionStats
+	var run models.ExecutionResponse
+	for i, test := range tests {
+		testJob := job
+		testJob.Stdin = test.Input
+		run = executeJob(ctx, testJob)
+		if ctx.Err() != nil {
+			return models.CheckResponse{Verdict: models.VerdictCancelled}
+		}
+		if run.Stats != nil {
+			stats = worstStats(stats, *run.Stats)
+		}
+		log.Printf("📊 Test %d/%d: success=%t, output_length=%d", i+1, len(tests), run.Success, len(run.Output))
+
+		response := models.CheckResponse{
+			Output:      run.Output,
+			Expected:    test.ExpectedOutput,
+			Actual:      strings.TrimSpace(run.Output),
+			Verdict:     run.Verdict,
+			Stats:       &stats,
+			ImageDigest: run.ImageDigest,
+			Backend:     run.Backend,
+		}
+		testName := "Тест"
+		if len(tests) > 1 {
+			testName = fmt.Sprintf("Тест %d", i+1)
+		}
 
-	// Для задачи "Hello World" проверяем что вывод содержит ожидаемую строку
-	if taskID == "1" || taskID == "hello_world" {
-		passed = strings.Contains(actualTrimmed, expected)
-	} else {
-		// Для других задач строгое сравнение
-		passed = actualTrimmed == expected
-	}
+		// Упавшую программу не проверяем: чекер может быть дорогим
+		if !run.Success {
+			response.Message = fmt.Sprintf("❌ %s не пройден: программа завершилась с ошибкой", testName)
+			return response
+		}
 
-	log.Printf("📊 Test comparison: expected='%s', actual='%s', passed=%t",
-		expected, actualTrimmed, passed)
+		result, err := judgeOutput(ctx, cfg, test.Input, test.ExpectedOutput, run.Output)
+		if err != nil {
+			log.Printf("❌ Checker error for task %s: %v", taskID, err)
+			response.Message = "⚠️ Ошибка проверки"
+			response.Verdict = models.VerdictCheckerError
+			return response
+		}
+		log.Printf("📊 Test comparison (%s): expected='%s', actual='%s', passed=%t",
+			cfg.Mode, test.ExpectedOutput, response.Actual, result.Passed)
+		if !result.Passed {
+			response.Message = fmt.Sprintf("❌ %s не пройден: %s", testName, result.Message)
+			response.Verdict = models.VerdictWrongAnswer
+			return response
+		}
+	}
 
 	message := "✅ Тест пройден!"
-	if !passed {
-		message = "❌ Тест не пройден"
+	if len(tests) > 1 {
+		message = fmt.Sprintf("✅ Пройдено тестов: %d из %d", len(tests), len(tests))
 	}
-
-	return models.CheckResult{
-		Passed:   passed,
-		Expected: expected,
-		Actual:   actualTrimmed,
-		Message:  message,
+	last := tests[len(tests)-1]
+	return models.CheckResponse{
+		Success:     true,
+		Passed:      true,
+		Output:      run.Output,
+		Expected:    last.ExpectedOutput,
+		Actual:      strings.TrimSpace(run.Output),
+		Message:     message,
+		Verdict:     models.VerdictOK,
+		Stats:       &stats,
+		ImageDigest: run.ImageDigest,
+		Backend:     run.Backend,
 	}
 }
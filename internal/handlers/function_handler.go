@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/harness"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Проверка задач на функцию: код ученика оборачивается обвязкой со всеми
+// тестами, программа собирается один раз (кэш сборки) и запускается для
+// каждого теста по очереди. Первый непройденный тест останавливает проверку
+
+// checkFunction прогоняет все тесты задачи. Замеры - худшие по всем тестам
+func checkFunction(ctx context.Context, task *models.Task, job executor.Job) models.CheckResponse {
+	lang, _ := languageRegistry.Lookup(job.Language)
+	if !harness.Supported(lang.ID) {
+		return models.CheckResponse{
+			Message: fmt.Sprintf("Задачи на функцию пока не поддерживают %s", lang.Name),
+			Verdict: models.VerdictUnsupported,
+		}
+	}
+
+	cfg := task.Checker
+	if cfg.Mode == "" {
+		cfg.Mode = harness.DefaultMode(*task.Function)
+	}
+
+	inputs := make([]string, len(task.Tests))
+	for i, test := range task.Tests {
+		if _, err := harness.ParseArgs(*task.Function, test.Input); err != nil {
+			log.Printf("❌ Invalid test %d of task %s: %v", i+1, task.ID, err)
+			return models.CheckResponse{
+				Message: fmt.Sprintf("⚠️ Ошибка в тесте %d", i+1),
+				Verdict: models.VerdictCheckerError,
+			}
+		}
+		inputs[i] = test.Input
+	}
+	code, err := harness.Generate(lang.ID, *task.Function, job.Code, inputs)
+	if err != nil {
+		log.Printf("❌ Invalid function of task %s: %v", task.ID, err)
+		return models.CheckResponse{
+			Message: "⚠️ Ошибка в описании функции задачи",
+			Verdict: models.VerdictCheckerError,
+		}
+	}
+
+	var stats models.ExecutionStats
+	var output, digest, backend string
+	for i, test := range task.Tests {
+		log.Printf("🧪 Function test %d/%d: %s(%s)", i+1, len(task.Tests), task.Function.Name, test.Input)
+
+		testJob := job
+		testJob.Code = code
+		testJob.RunFiles = harness.SelectTest(i)
+		run := executeJob(ctx, testJob)
+		if ctx.Err() != nil {
+			return models.CheckResponse{Verdict: models.VerdictCancelled}
+		}
+		if run.Stats != nil {
+			stats = worstStats(stats, *run.Stats)
+		}
+		output = run.Output
+		digest = run.ImageDigest
+		backend = run.Backend
+
+		testName := fmt.Sprintf("Тест %d: %s(%s)", i+1, task.Function.Name, test.Input)
+		if !run.Success {
+			return models.CheckResponse{
+				Output:      run.Output,
+				Message:     fmt.Sprintf("❌ %s - программа завершилась с ошибкой", testName),
+				Verdict:     run.Verdict,
+				Stats:       &stats,
+				ImageDigest: digest,
+				Backend:     backend,
+			}
+		}
+
+		result, err := judgeOutput(ctx, cfg, test.Input, test.ExpectedOutput, run.Output)
+		if err != nil {
+			log.Printf("❌ Checker error for task %s: %v", task.ID, err)
+			return models.CheckResponse{
+				Output:      run.Output,
+				Message:     "⚠️ Ошибка проверки",
+				Verdict:     models.VerdictCheckerError,
+				Stats:       &stats,
+				ImageDigest: digest,
+				Backend:     backend,
+			}
+		}
+		if !result.Passed {
+			return models.CheckResponse{
+				Output:      run.Output,
+				Expected:    test.ExpectedOutput,
+				Actual:      strings.TrimSpace(run.Output),
+				Message:     fmt.Sprintf("❌ %s - %s", testName, result.Message),
+				Verdict:     models.VerdictWrongAnswer,
+				Stats:       &stats,
+				ImageDigest: digest,
+				Backend:     backend,
+			}
+		}
+	}
+
+	return models.CheckResponse{
+		Success:     true,
+		Passed:      true,
+		Output:      output,
+		Message:     fmt.Sprintf("✅ Пройдено тестов: %d из %d", len(task.Tests), len(task.Tests)),
+		Verdict:     models.VerdictOK,
+		Stats:       &stats,
+		ImageDigest: digest,
+		Backend:     backend,
+	}
+}
+
+// worstStats - максимум каждого замера
+func worstStats(a, b models.ExecutionStats) models.ExecutionStats {
+	return models.ExecutionStats{
+		WallTimeMs:   max(a.WallTimeMs, b.WallTimeMs),
+		CPUTimeMs:    max(a.CPUTimeMs, b.CPUTimeMs),
+		PeakMemoryKB: max(a.PeakMemoryKB, b.PeakMemoryKB),
+	}
+}
+
+// DefaultCode - код для редактора: заготовка функции для задач на функцию,
+// иначе шаблон языка
+func DefaultCode(taskID string, lang *languages.Language) string {
+	if task, ok := findTask(taskID); ok && task.Function != nil {
+		if stub := harness.Stub(lang.ID, *task.Function); stub != "" {
+			return stub
+		}
+	}
+	return lang.Template
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"backend/internal/languages"
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Список языков и их реальная доступность на сервере
+
+var toolchains *languages.Prober // Что из реестра реально установлено
+
+// LanguageInfo - язык в ответе /api/languages
+type LanguageInfo struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Aliases  []string         `json:"aliases,omitempty"`
+	Version  string           `json:"version,omitempty"`
+	Template string           `json:"template,omitempty"`
+	Status   languages.Status `json:"status"`
+}
+
+// Toolchains - последние результаты проверки языков (для /health)
+func Toolchains() []languages.Status {
+	return toolchains.Statuses()
+}
+
+// LanguagesHandler - GET /api/languages
+func LanguagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var response []LanguageInfo
+	for _, lang := range languageRegistry.All() {
+		status, _ := toolchains.Status(lang.ID)
+		response = append(response, LanguageInfo{
+			ID:       lang.ID,
+			Name:     lang.Name,
+			Aliases:  lang.Aliases,
+			Version:  lang.Version,
+			Template: lang.Template,
+			Status:   status,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkLanguage проверяет, что язык известен и его можно запустить.
+// Возвращает текст ошибки и HTTP статус, либо пустую строку
+func checkLanguage(language string) (string, int) {
+	lang, ok := languageRegistry.Lookup(language)
+	if !ok {
+		return fmt.Sprintf("Unsupported language: %s. Use: %s", language, strings.Join(languageRegistry.IDs(), ", ")), http.StatusBadRequest
+	}
+
+	status, ok := toolchains.Status(lang.ID)
+	if ok && !status.Available {
+		message := fmt.Sprintf("%s is not available on this server", lang.Name)
+		if len(status.Missing) > 0 {
+			message += " (missing " + strings.Join(status.Missing, ", ") + ")"
+		}
+		return message, http.StatusServiceUnavailable
+	}
+	return "", http.StatusOK
+}
+
+// writeLanguageError отвечает отказом выполнять код на недоступном языке
+func writeLanguageError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ExecutionResponse{
+		Success: false,
+		Message: message,
+		Verdict: models.VerdictUnsupported,
+	})
+}
+
+// writeFilesError - файлы решения не прошли проверку (пути, количество, размер)
+func writeFilesError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(models.ExecutionResponse{
+		Success: false,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"backend/internal/leaderboard"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Таблицы лидеров: общая, по треку (теме задач) и по группе. Считаются
+// в памяти из user_progress при старте и обновляются после каждой проверки,
+// поэтому запросы таблиц в базу не ходят
+
+// Размер страницы таблицы и окна "вокруг меня"
+const (
+	defaultLeaderboardLimit  = 50
+	maxLeaderboardLimit      = 200
+	defaultLeaderboardRadius = 5
+	maxLeaderboardRadius     = 50
+)
+
+// loadLeaderboards собирает таблицы из прогресса и настроек всех учеников.
+// Если загрузить не удалось, таблицы начинаются пустыми
+func loadLeaderboards(settings leaderboard.SettingsStore) *leaderboard.Leaderboard {
+	topics := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		topics[task.ID] = task.Topic
+	}
+	board := leaderboard.New(topics, settings)
+
+	ctx := context.Background()
+	records, err := progressStore.All(ctx)
+	if err != nil {
+		log.Printf("⚠️ Leaderboards start empty: %v", err)
+		return board
+	}
+	items, err := settings.List(ctx)
+	if err != nil {
+		log.Printf("⚠️ Leaderboards start empty: %v", err)
+		return board
+	}
+	board.Load(records, items)
+	log.Printf("🏆 Leaderboards loaded: %d progress records", len(records))
+	return board
+}
+
+// LeaderboardHandler - GET /api/leaderboard?track=&group=&limit=&offset=
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	scope, ok := leaderboardScope(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"), defaultLeaderboardLimit, 1, maxLeaderboardLimit)
+	if err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid limit"}`, http.StatusBadRequest)
+		return
+	}
+	offset, err := queryInt(query.Get("offset"), 0, 0, -1)
+	if err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid offset"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Вход не обязателен: вошедшему дополнительно показываем его место
+	me := ""
+	if user := currentUser(r); user != nil {
+		me = user.ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboards.Page(scope, offset, limit, me))
+}
+
+// LeaderboardAroundMeHandler - GET /api/leaderboard/around-me?track=&group=&radius=
+func LeaderboardAroundMeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := currentUser(r)
+	if user == nil {
+		http.Error(w, `{"success": false, "message": "Authorization required"}`, http.StatusUnauthorized)
+		return
+	}
+	scope, ok := leaderboardScope(w, r)
+	if !ok {
+		return
+	}
+	radius, err := queryInt(r.URL.Query().Get("radius"), defaultLeaderboardRadius, 0, maxLeaderboardRadius)
+	if err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid radius"}`, http.StatusBadRequest)
+		return
+	}
+
+	page, ok := leaderboards.Around(scope, user.ID, radius)
+	if !ok {
+		http.Error(w, `{"success": false, "message": "You are not on this leaderboard"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// MeLeaderboardHandler - GET/PUT /api/me/leaderboard: имя в таблицах,
+// группа и отказ от участия (hidden)
+func MeLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if user == nil {
+		http.Error(w, `{"success": false, "message": "Authorization required"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(leaderboards.Settings(user.ID))
+	case "PUT":
+		var settings leaderboard.Settings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
+			return
+		}
+		settings.UserID = user.ID
+
+		saved, err := leaderboards.UpdateSettings(r.Context(), settings)
+		switch {
+		case errors.Is(err, leaderboard.ErrInvalidSettings):
+			http.Error(w, `{"success": false, "message": "Name and group must be at most 50 characters"}`, http.StatusBadRequest)
+			return
+		case err != nil:
+			log.Printf("❌ %v", err)
+			http.Error(w, `{"success": false, "message": "Failed to save leaderboard settings"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(saved)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// leaderboardScope - таблица из query: track - тема задач, group - код
+// группы. Пусто - общая таблица
+func leaderboardScope(w http.ResponseWriter, r *http.Request) (leaderboard.Scope, bool) {
+	query := r.URL.Query()
+	scope := leaderboard.Scope{
+		Track: strings.TrimSpace(query.Get("track")),
+		Group: strings.TrimSpace(query.Get("group")),
+	}
+	if scope.Track != "" && scope.Group != "" {
+		http.Error(w, `{"success": false, "message": "Use either track or group"}`, http.StatusBadRequest)
+		return leaderboard.Scope{}, false
+	}
+	if scope.Track != "" && !knownTopic(scope.Track) {
+		http.Error(w, `{"success": false, "message": "Unknown track"}`, http.StatusBadRequest)
+		return leaderboard.Scope{}, false
+	}
+	return scope, true
+}
+
+func knownTopic(topic string) bool {
+	for _, task := range tasks {
+		if task.Topic == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"backend/internal/progress"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Прогресс ученика: каждая проверка решения вошедшего пользователя
+// учитывается в user_progress
+
+// recordProgress учитывает проверку в прогрессе ученика в фоне.
+// Анонимные посылки, неизвестные задачи и непроверенные посылки
+// (отмена, сбой проверки) не учитываются
+func recordProgress(r *http.Request, taskID string, response models.CheckResponse) {
+	user := currentUser(r)
+	if user == nil || !progress.Judged(response.Verdict) {
+		return
+	}
+	if _, ok := findTask(taskID); !ok {
+		return
+	}
+	score := progress.Score(response)
+	go func() {
+		at := time.Now()
+		if err := progressStore.Record(context.Background(), user.ID, taskID, response.Passed, score, at); err != nil {
+			log.Printf("⚠️ %v", err)
+			return
+		}
+		if err := leaderboards.Record(context.Background(), user, taskID, response.Passed, score, at); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}()
+}
+
+// taskStatuses - статусы задач ученика по ID задачи. Без пользователя - nil
+func taskStatuses(ctx context.Context, user *models.User) map[string]string {
+	if user == nil {
+		return nil
+	}
+	records, err := progressStore.List(ctx, user.ID)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		return nil
+	}
+	statuses := make(map[string]string, len(records))
+	for i := range records {
+		statuses[records[i].TaskID] = progress.Status(&records[i])
+	}
+	return statuses
+}
+
+// MeProgressHandler - GET /api/me/progress: статус каждой задачи и доля
+// решенных по темам и в целом
+func MeProgressHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := currentUser(r)
+	if user == nil {
+		http.Error(w, `{"success": false, "message": "Authorization required"}`, http.StatusUnauthorized)
+		return
+	}
+
+	records, err := progressStore.List(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		http.Error(w, `{"success": false, "message": "Failed to load progress"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress.Summarize(user.ID, tasks, records))
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Интерактивные сессии через WebSocket.
+// Клиент первым сообщением присылает {"type":"start","language":...,"code":...}
+// (и task_id, если запуск идет с лимитами задачи),
+// дальше {"type":"stdin","data":...} и {"type":"eof"} для закрытия ввода.
+// Сервер отвечает событиями stdout/stderr и финальным exit.
+// Сессия завершается по выходу программы, таймауту или отключению клиента
+
+const (
+	// sessionTimeout - сколько максимум живет интерактивная сессия (с учетом ввода)
+	sessionTimeout = 5 * time.Minute
+	// sessionStartTimeout - сколько ждем первое сообщение с кодом
+	sessionStartTimeout = 10 * time.Second
+	// sessionInputQueue - сколько непрочитанных программой сообщений ввода держим
+	sessionInputQueue = 256
+)
+
+// SessionMessage - сообщение от клиента
+type SessionMessage struct {
+	Type     string `json:"type"` // start, stdin, eof
+	TaskID   string `json:"task_id,omitempty"`
+	Language string `json:"language,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Data     string `json:"data,omitempty"`
+	// Files - остальные файлы решения (только в start)
+	Files []models.SourceFile `json:"files,omitempty"`
+}
+
+// SessionEvent - сообщение клиенту
+type SessionEvent struct {
+	Type     string `json:"type"` // stdout, stderr, exit, error
+	Data     string `json:"data,omitempty"`
+	Success  bool   `json:"success,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Verdict  string `json:"verdict,omitempty"`
+
+	Stats       *models.ExecutionStats `json:"stats,omitempty"`
+	ImageDigest string                 `json:"image_digest,omitempty"`
+	Backend     string                 `json:"backend,omitempty"`
+}
+
+// sessionConn сериализует отправку: stdout и stderr пишутся из разных горутин
+type sessionConn struct {
+	mu      sync.Mutex
+	ws      *websocket.Conn
+	written bool
+}
+
+func (c *sessionConn) send(event SessionEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if event.Type == "stdout" || event.Type == "stderr" {
+		c.written = true
+	}
+	return websocket.JSON.Send(c.ws, event)
+}
+
+func (c *sessionConn) hasOutput() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.written
+}
+
+// sessionWriter - io.Writer, отправляющий вывод программы клиенту
+type sessionWriter struct {
+	conn   *sessionConn
+	stream string
+}
+
+func (w *sessionWriter) Write(p []byte) (int, error) {
+	if err := w.conn.send(SessionEvent{Type: w.stream, Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewSessionHandler создает обработчик /api/session.
+// Браузерные подключения принимаются только с allowedOrigins
+func NewSessionHandler(allowedOrigins []string) http.Handler {
+	return websocket.Server{
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				// Не браузерный клиент
+				return nil
+			}
+			for _, allowed := range allowedOrigins {
+				if origin == allowed {
+					return nil
+				}
+			}
+			log.Printf("❌ WebSocket origin rejected: %s", origin)
+			return errors.New("origin not allowed")
+		},
+		Handler: handleSession,
+	}
+}
+
+func handleSession(ws *websocket.Conn) {
+	defer ws.Close()
+
+	conn := &sessionConn{ws: ws}
+
+	// Таймауты http.Server остаются на захваченном соединении, ждем старт отдельно
+	ws.SetDeadline(time.Now().Add(sessionStartTimeout))
+
+	var start SessionMessage
+	if err := websocket.JSON.Receive(ws, &start); err != nil || start.Type != "start" {
+		conn.send(SessionEvent{Type: "error", Message: "First message must be {\"type\":\"start\"}"})
+		return
+	}
+	ws.SetDeadline(time.Time{})
+
+	if message, _ := checkLanguage(start.Language); message != "" {
+		log.Printf("❌ %s", message)
+		conn.send(SessionEvent{Type: "error", Message: message, Verdict: models.VerdictUnsupported})
+		return
+	}
+
+	job := newJob(start.TaskID, start.Code, start.Language, start.Files)
+	if err := job.Validate(); err != nil {
+		log.Printf("❌ %v", err)
+		conn.send(SessionEvent{Type: "error", Message: err.Error()})
+		return
+	}
+
+	log.Printf("🖥️ Interactive session started for language: %s", start.Language)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionTimeout)
+	defer cancel()
+
+	stdin, stdinWriter := io.Pipe()
+	defer stdin.Close()
+
+	// Очередь ввода: чтение из сокета не должно блокироваться, пока программа
+	// не читает stdin, иначе мы не заметим отключение клиента
+	input := make(chan []byte, sessionInputQueue)
+	go func() {
+		for data := range input {
+			if _, err := stdinWriter.Write(data); err != nil {
+				break
+			}
+		}
+		stdinWriter.Close()
+	}()
+
+	go func() {
+		defer cancel()
+		closed := false
+		closeInput := func() {
+			if !closed {
+				closed = true
+				close(input)
+			}
+		}
+		defer closeInput()
+
+		for {
+			var msg SessionMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("🔌 Interactive session client disconnected: %v", err)
+				}
+				return
+			}
+
+			switch msg.Type {
+			case "stdin":
+				if closed {
+					continue
+				}
+				select {
+				case input <- []byte(msg.Data):
+				default:
+					conn.send(SessionEvent{Type: "error", Message: "Input buffer is full"})
+				}
+			case "eof":
+				closeInput()
+			}
+		}
+	}()
+
+	streams := executor.Streams{
+		Stdin:  stdin,
+		Stdout: &sessionWriter{conn: conn, stream: "stdout"},
+		Stderr: &sessionWriter{conn: conn, stream: "stderr"},
+	}
+
+	verdict := jobRunner.Stream(ctx, job, streams, conn.hasOutput)
+
+	log.Printf("🏁 Interactive session finished: success=%t", verdict.Success)
+
+	conn.send(SessionEvent{
+		Type:        "exit",
+		Success:     verdict.Success,
+		ExitCode:    verdict.ExitCode,
+		Message:     verdict.Message,
+		Error:       verdict.Error,
+		Verdict:     verdict.Verdict,
+		Stats:       verdict.Stats,
+		ImageDigest: verdict.ImageDigest,
+		Backend:     verdict.Backend,
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/judge"
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Потоковое выполнение кода через Server-Sent Events.
+// Клиент получает события stdout/stderr по мере работы программы,
+// а в конце одно событие verdict с итогом
+
+// StreamChunk - кусок вывода программы
+type StreamChunk struct {
+	Data string `json:"data"`
+}
+
+// StreamVerdict - финальное событие потока
+type StreamVerdict struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exitCode"`
+	Verdict  string `json:"verdict,omitempty"`
+
+	Stats       *models.ExecutionStats `json:"stats,omitempty"`
+	ImageDigest string                 `json:"image_digest,omitempty"`
+	Backend     string                 `json:"backend,omitempty"`
+}
+
+// sseWriter пишет события в ответ. stdout и stderr пишутся из разных горутин,
+// поэтому запись под мьютексом
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	written bool
+}
+
+func (s *sseWriter) send(event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	if event != "verdict" {
+		s.written = true
+	}
+	return nil
+}
+
+func (s *sseWriter) hasOutput() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written
+}
+
+// streamWriter - io.Writer, превращающий каждую запись в событие
+type streamWriter struct {
+	sse   *sseWriter
+	event string
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if err := s.sse.send(s.event, StreamChunk{Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ExecuteStreamHandler - POST /api/execute/stream
+func ExecuteStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, `{"success": false, "message": "Only POST method allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ExecutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+
+	if message, status := checkLanguage(req.Language); message != "" {
+		log.Printf("❌ %s", message)
+		writeLanguageError(w, message, status)
+		return
+	}
+
+	job := newJob(req.TaskID, req.Code, req.Language, req.Files)
+	if err := job.Validate(); err != nil {
+		log.Printf("❌ %v", err)
+		writeFilesError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"success": false, "message": "Streaming not supported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Программа может работать дольше WriteTimeout сервера
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("⚠️ Failed to reset write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+	streams := executor.Streams{
+		Stdout: &streamWriter{sse: sse, event: "stdout"},
+		Stderr: &streamWriter{sse: sse, event: "stderr"},
+	}
+
+	log.Printf("📡 Streaming execution for language: %s", req.Language)
+
+	verdict := streamVerdict(jobRunner.Stream(r.Context(), job, streams, sse.hasOutput))
+	if err := sse.send("verdict", verdict); err != nil {
+		log.Printf("❌ Failed to send verdict: %v", err)
+	}
+}
+
+// streamVerdict - итог запуска в формате события verdict
+func streamVerdict(result judge.StreamResult) StreamVerdict {
+	return StreamVerdict{
+		Success:     result.Success,
+		Message:     result.Message,
+		Error:       result.Error,
+		ExitCode:    result.ExitCode,
+		Verdict:     result.Verdict,
+		Stats:       result.Stats,
+		ImageDigest: result.ImageDigest,
+		Backend:     result.Backend,
+	}
+}
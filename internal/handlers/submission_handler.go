@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/diff"
+	"backend/internal/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// История посылок ученика из code_executions: список проверок, посылка
+// с кодом и результатами тестов, построчное сравнение двух посылок.
+// Ученик видит только свои посылки, чужие отвечают 404
+
+// Размер страницы списка посылок
+const (
+	defaultSubmissionsLimit = 50
+	maxSubmissionsLimit     = 200
+)
+
+// FileDiff - сравнение одного файла двух посылок
+type FileDiff struct {
+	Path    string      `json:"path"`
+	Status  string      `json:"status"` // added, removed, modified, unchanged
+	Added   int         `json:"added"`
+	Removed int         `json:"removed"`
+	Lines   []diff.Line `json:"lines,omitempty"`
+}
+
+// SubmissionDiff - ответ /api/submissions/diff
+type SubmissionDiff struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Files []FileDiff `json:"files"`
+}
+
+// MySubmissionsHandler - GET /api/me/submissions?task_id=&language=&verdict=&limit=&offset=
+func MySubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := historyUser(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := database.SubmissionFilter{
+		UserID:  user.ID,
+		TaskID:  query.Get("task_id"),
+		Verdict: query.Get("verdict"),
+		Limit:   defaultSubmissionsLimit,
+	}
+	if language := query.Get("language"); language != "" {
+		// В истории язык хранится по id, алиасы тоже принимаем
+		filter.Language = language
+		if lang, ok := languageRegistry.Lookup(language); ok {
+			filter.Language = lang.ID
+		}
+	}
+	var err error
+	if filter.Limit, err = queryInt(query.Get("limit"), defaultSubmissionsLimit, 1, maxSubmissionsLimit); err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid limit"}`, http.StatusBadRequest)
+		return
+	}
+	if filter.Offset, err = queryInt(query.Get("offset"), 0, 0, -1); err != nil {
+		http.Error(w, `{"success": false, "message": "Invalid offset"}`, http.StatusBadRequest)
+		return
+	}
+
+	submissions, err := executionStore.ListSubmissions(r.Context(), filter)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		http.Error(w, `{"success": false, "message": "Failed to load submissions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"submissions": submissions,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+	})
+}
+
+// SubmissionHandler - GET /api/submissions/{id} и GET /api/submissions/diff?from=&to=
+func SubmissionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := historyUser(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/submissions/")
+	if id == "diff" {
+		submissionDiff(w, r, user)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	submission, ok := loadSubmission(w, r, user, id)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(submission)
+}
+
+// submissionDiff сравнивает файлы посылок from и to построчно
+func submissionDiff(w http.ResponseWriter, r *http.Request, user *models.User) {
+	fromID, toID := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if fromID == "" || toID == "" {
+		http.Error(w, `{"success": false, "message": "from and to are required"}`, http.StatusBadRequest)
+		return
+	}
+	from, ok := loadSubmission(w, r, user, fromID)
+	if !ok {
+		return
+	}
+	to, ok := loadSubmission(w, r, user, toID)
+	if !ok {
+		return
+	}
+
+	oldFiles, newFiles := submissionFiles(from), submissionFiles(to)
+	paths := make([]string, 0, len(oldFiles)+len(newFiles))
+	for path := range oldFiles {
+		paths = append(paths, path)
+	}
+	for path := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	response := SubmissionDiff{From: from.ID, To: to.ID, Files: []FileDiff{}}
+	for _, path := range paths {
+		oldContent, inOld := oldFiles[path]
+		newContent, inNew := newFiles[path]
+		file := FileDiff{Path: path, Status: "modified"}
+		switch {
+		case !inOld:
+			file.Status = "added"
+		case !inNew:
+			file.Status = "removed"
+		case oldContent == newContent:
+			file.Status = "unchanged"
+			response.Files = append(response.Files, file)
+			continue
+		}
+
+		lines, err := diff.Lines(oldContent, newContent)
+		if errors.Is(err, diff.ErrTooLarge) {
+			http.Error(w, `{"success": false, "message": "Submissions are too large to compare"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		file.Lines = lines
+		file.Added, file.Removed = diff.Count(lines)
+		response.Files = append(response.Files, file)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// historyUser - вошедший пользователь для запросов истории. История
+// читается из базы, без нее отвечаем 503
+func historyUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user := currentUser(r)
+	if user == nil {
+		http.Error(w, `{"success": false, "message": "Authorization required"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	if executionStore == nil {
+		http.Error(w, `{"success": false, "message": "Submission history requires a database"}`, http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return user, true
+}
+
+// loadSubmission загружает посылку ученика. Запуски без проверки и чужие
+// посылки для него не существуют
+func loadSubmission(w http.ResponseWriter, r *http.Request, user *models.User, id string) (*models.ExecutionResult, bool) {
+	submission, err := executionStore.Get(r.Context(), id)
+	if err == nil && (submission.UserID != user.ID || submission.Kind != models.KindCheck) {
+		err = database.ErrNotFound
+	}
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		http.Error(w, `{"success": false, "message": "Submission not found"}`, http.StatusNotFound)
+		return nil, false
+	case err != nil:
+		log.Printf("❌ %v", err)
+		http.Error(w, `{"success": false, "message": "Failed to load submission"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	return submission, true
+}
+
+// submissionFiles - файлы посылки по путям: основной файл языка и
+// остальные файлы решения
+func submissionFiles(submission *models.ExecutionResult) map[string]string {
+	mainFile := "main"
+	if lang, ok := languageRegistry.Lookup(submission.Language); ok {
+		mainFile = lang.FileName
+	}
+	files := map[string]string{mainFile: submission.Code}
+	for _, file := range submission.Files {
+		files[file.Path] = file.Content
+	}
+	return files
+}
+
+// queryInt разбирает число из query. Пусто - def, hi < 0 - без верхней границы
+func queryInt(value string, def, lo, hi int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || (hi >= 0 && n > hi) {
+		return 0, errors.New("out of range")
+	}
+	return n, nil
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"backend/internal/checker"
+	"backend/internal/executor"
 	"backend/internal/models"
 	"encoding/json"
 	"net/http"
@@ -12,8 +14,12 @@ var tasks = []models.Task{
 	{
 		ID:          "1",
 		Title:       "Hello World",
+		Topic:       "basics",
 		Description: "Напишите программу которая выводит 'Hello, World!'",
 		Template:    "print('Hello, World!')",
+		Limits:      models.TaskLimits{TimeLimitMs: 2000, MemoryLimitMB: 64, OutputLimitKB: 64},
+		// Шаблоны языков печатают "Hello World", принимаем оба варианта
+		Checker: models.CheckerConfig{Mode: checker.ModeWords},
 		Tests: []models.Test{
 			{
 				Input:          "",
@@ -24,8 +30,10 @@ var tasks = []models.Task{
 	{
 		ID:          "2",
 		Title:       "Сумма двух чисел",
+		Topic:       "functions",
 		Description: "Напишите функцию sum(a, b) которая возвращает сумму двух чисел",
-		Template:    "def sum(a, b):\n    # Ваш код здесь\n    pass\n\n# Тестирование\nresult = sum(2, 3)\nprint(result)",
+		Template:    "def sum(a, b):\n    # Ваш код здесь\n    pass\n",
+		Limits:      models.TaskLimits{TimeLimitMs: 2000, MemoryLimitMB: 64, OutputLimitKB: 64},
 		Tests: []models.Test{
 			{
 				Input:          "2, 3",
@@ -36,17 +44,70 @@ var tasks = []models.Task{
 				ExpectedOutput: "5",
 			},
 		},
+		Checker: models.CheckerConfig{Mode: checker.ModeTokens},
+		Function: &models.FunctionSignature{
+			Name:    "sum",
+			Params:  []models.Param{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+			Returns: "int",
+		},
 	},
 	{
 		ID:          "3",
 		Title:       "Факториал",
+		Topic:       "functions",
 		Description: "Напишите функцию для вычисления факториала числа",
-		Template:    "def factorial(n):\n    # Ваш код здесь\n    pass\n\n# Тестирование\nprint(factorial(5))",
+		Template:    "def factorial(n):\n    # Ваш код здесь\n    pass\n",
+		Limits:      models.TaskLimits{TimeLimitMs: 3000, MemoryLimitMB: 128, OutputLimitKB: 64},
 		Tests: []models.Test{
 			{
 				Input:          "5",
 				ExpectedOutput: "120",
 			},
+			{
+				Input:          "0",
+				ExpectedOutput: "1",
+			},
+			{
+				Input:          "20",
+				ExpectedOutput: "2432902008176640000",
+			},
+		},
+		Function: &models.FunctionSignature{
+			Name:    "factorial",
+			Params:  []models.Param{{Name: "n", Type: "int"}},
+			Returns: "long",
+		},
+	},
+	{
+		ID:          "4",
+		Title:       "Палиндром",
+		Topic:       "strings",
+		Description: "Напишите функцию is_palindrome(s) (isPalindrome в JavaScript, Java, C++ и IsPalindrome в Go), которая проверяет, что строка читается одинаково в обе стороны без учета регистра и пробелов. Решение проверяется юнит-тестами",
+		Template:    "def is_palindrome(s):\n    # Ваш код здесь\n    pass\n",
+		Limits:      models.TaskLimits{TimeLimitMs: 5000, MemoryLimitMB: 256, OutputLimitKB: 256},
+		UnitTests: map[string]string{
+			"python":     palindromePytest,
+			"javascript": palindromeJest,
+			"java":       palindromeJUnit,
+			"cpp":        palindromeGoogleTest,
+			"go":         palindromeGoTest,
+		},
+	},
+	{
+		ID:          "5",
+		Title:       "Сумма из файла",
+		Topic:       "files",
+		Description: "В файле data/numbers.txt записаны целые числа, по одному в строке. Выведите их сумму. Файл доступен только для чтения",
+		Template:    "with open('data/numbers.txt') as f:\n    # Ваш код здесь\n    pass\n",
+		Limits:      models.TaskLimits{TimeLimitMs: 2000, MemoryLimitMB: 64, OutputLimitKB: 64},
+		Files: []models.TaskFile{
+			{Path: "data/numbers.txt", Content: "17\n-4\n250\n8\n1000\n-21\n", Hidden: true},
+		},
+		Tests: []models.Test{
+			{
+				Input:          "",
+				ExpectedOutput: "1250",
+			},
 		},
 	},
 }
@@ -57,17 +118,99 @@ func TasksHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Вошедшему ученику отмечаем решенные и начатые задачи
+	statuses := taskStatuses(r.Context(), currentUser(r))
+
 	// Возвращаем задачи без тестов (для безопасности)
 	var publicTasks []models.Task
 	for _, task := range tasks {
 		publicTasks = append(publicTasks, models.Task{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Template:    task.Template,
+			ID:              task.ID,
+			Title:           task.Title,
+			Topic:           task.Topic,
+			Description:     task.Description,
+			Template:        task.Template,
+			Limits:          task.Limits,
+			Checker:         publicChecker(task.Checker),
+			Function:        task.Function,
+			Files:           publicFiles(task.Files),
+			EffectiveLimits: effectiveLimitsByLanguage(task.Limits),
+			Status:          statuses[task.ID],
 		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(publicTasks)
 }
+
+// publicChecker - настройки чекера без исходного кода программы-чекера
+func publicChecker(cfg models.CheckerConfig) models.CheckerConfig {
+	cfg.Source = ""
+	return cfg
+}
+
+// publicFiles - файлы задачи без скрытых
+func publicFiles(files []models.TaskFile) []models.TaskFile {
+	var public []models.TaskFile
+	for _, file := range files {
+		if !file.Hidden {
+			public = append(public, file)
+		}
+	}
+	return public
+}
+
+// findTask ищет задачу по ID
+func findTask(id string) (*models.Task, bool) {
+	for i := range tasks {
+		if tasks[i].ID == id {
+			return &tasks[i], true
+		}
+	}
+	return nil, false
+}
+
+// effectiveLimitsByLanguage - лимиты задачи для каждого языка из реестра
+func effectiveLimitsByLanguage(limits models.TaskLimits) map[string]models.TaskLimits {
+	result := make(map[string]models.TaskLimits)
+	for _, lang := range languageRegistry.All() {
+		result[lang.ID] = executor.EffectiveLimits(limits, lang, outputLimit)
+	}
+	return result
+}
+
+// TaskLimits - действующие лимиты задачи taskID на языке language.
+// Для неизвестной задачи - лимиты языка по умолчанию
+func TaskLimits(taskID, language string) (models.TaskLimits, bool) {
+	lang, ok := languageRegistry.Lookup(language)
+	if !ok {
+		return models.TaskLimits{}, false
+	}
+	var limits models.TaskLimits
+	if task, ok := findTask(taskID); ok {
+		limits = task.Limits
+	}
+	return executor.EffectiveLimits(limits, lang, outputLimit), true
+}
+
+// newJob собирает задание исполнителю с лимитами и файлами задачи taskID.
+// Пути файлов не проверяются - перед запуском нужен job.Validate()
+func newJob(taskID, code, language string, files []models.SourceFile) executor.Job {
+	job := executor.Job{Code: code, Language: language}
+	if limits, ok := TaskLimits(taskID, language); ok {
+		job.Limits = limits
+	}
+	if len(files) > 0 {
+		job.Files = make(map[string]string, len(files))
+		for _, file := range files {
+			job.Files[file.Path] = file.Content
+		}
+	}
+	if task, ok := findTask(taskID); ok && len(task.Files) > 0 {
+		job.ReadOnlyFiles = make(map[string]string, len(task.Files))
+		for _, file := range task.Files {
+			job.ReadOnlyFiles[file.Path] = file.Content
+		}
+	}
+	return job
+}
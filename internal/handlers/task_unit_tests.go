@@ -0,0 +1,119 @@
+package handlers
+
+// Юнит-тесты учителя для задачи "Палиндром" на каждом языке.
+// Код ученика лежит рядом: solution.py, solution.js, Solution.java, solution.h, solution.go
+
+const palindromePytest = `from solution import is_palindrome
+
+
+def test_simple():
+    assert is_palindrome("level")
+
+
+def test_not_palindrome():
+    assert not is_palindrome("hello")
+
+
+def test_ignores_case_and_spaces():
+    assert is_palindrome("А роза упала на лапу Азора")
+
+
+def test_empty():
+    assert is_palindrome("")
+`
+
+const palindromeJest = `const { isPalindrome } = require("./solution");
+
+test("simple", () => {
+  expect(isPalindrome("level")).toBe(true);
+});
+
+test("not palindrome", () => {
+  expect(isPalindrome("hello")).toBe(false);
+});
+
+test("ignores case and spaces", () => {
+  expect(isPalindrome("А роза упала на лапу Азора")).toBe(true);
+});
+
+test("empty", () => {
+  expect(isPalindrome("")).toBe(true);
+});
+`
+
+const palindromeJUnit = `import static org.junit.jupiter.api.Assertions.*;
+
+import org.junit.jupiter.api.Test;
+
+class SolutionTest {
+    @Test
+    void simple() {
+        assertTrue(Solution.isPalindrome("level"));
+    }
+
+    @Test
+    void notPalindrome() {
+        assertFalse(Solution.isPalindrome("hello"));
+    }
+
+    @Test
+    void ignoresCaseAndSpaces() {
+        assertTrue(Solution.isPalindrome("А роза упала на лапу Азора"));
+    }
+
+    @Test
+    void empty() {
+        assertTrue(Solution.isPalindrome(""));
+    }
+}
+`
+
+const palindromeGoogleTest = `#include <gtest/gtest.h>
+
+#include "solution.h"
+
+TEST(Palindrome, Simple) {
+    EXPECT_TRUE(isPalindrome("level"));
+}
+
+TEST(Palindrome, NotPalindrome) {
+    EXPECT_FALSE(isPalindrome("hello"));
+}
+
+TEST(Palindrome, IgnoresCaseAndSpaces) {
+    EXPECT_TRUE(isPalindrome("Was it a car or a cat I saw"));
+}
+
+TEST(Palindrome, Empty) {
+    EXPECT_TRUE(isPalindrome(""));
+}
+`
+
+const palindromeGoTest = `package main
+
+import "testing"
+
+func TestSimple(t *testing.T) {
+	if !IsPalindrome("level") {
+		t.Error("level is a palindrome")
+	}
+}
+
+func TestNotPalindrome(t *testing.T) {
+	if IsPalindrome("hello") {
+		t.Error("hello is not a palindrome")
+	}
+}
+
+func TestIgnoresCaseAndSpaces(t *testing.T) {
+	if !IsPalindrome("А роза упала на лапу Азора") {
+		t.Error("case and spaces must be ignored")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if !IsPalindrome("") {
+		t.Error("empty string is a palindrome")
+	}
+}
+`
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"backend/internal/unittest"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Проверка юнит-тестами учителя: код ученика и файл тестов запускаются
+// тестовым фреймворком языка, отчет фреймворка разбирается по тестам
+
+// checkUnitTests запускает юнит-тесты задачи для решения job
+func checkUnitTests(ctx context.Context, task *models.Task, job executor.Job) models.CheckResponse {
+	lang, _ := languageRegistry.Lookup(job.Language)
+	source, ok := task.UnitTests[lang.ID]
+	if !ok || lang.Unit == nil {
+		available := make([]string, 0, len(task.UnitTests))
+		for id := range task.UnitTests {
+			available = append(available, id)
+		}
+		sort.Strings(available)
+		return models.CheckResponse{
+			Message: fmt.Sprintf("Для %s нет юнит-тестов этой задачи. Доступны: %s", lang.Name, strings.Join(available, ", ")),
+			Verdict: models.VerdictUnsupported,
+		}
+	}
+
+	log.Printf("🧪 Running %s unit tests for task %s", lang.Unit.Framework, task.ID)
+	job.Unit = true
+	// Файл тестов кладется поверх файлов решения и задачи
+	readOnly := map[string]string{lang.Unit.TestFile: source}
+	for name, content := range job.ReadOnlyFiles {
+		if name != lang.Unit.TestFile {
+			readOnly[name] = content
+		}
+	}
+	job.ReadOnlyFiles = readOnly
+	run := executeJob(ctx, job)
+
+	// go test пишет отчет в stdout, остальные фреймворки - в файл
+	report := run.Report
+	if lang.Unit.Report == "" {
+		report = run.Output
+	}
+
+	results, err := unittest.Parse(lang.Unit.Format, report)
+	if err != nil {
+		// До тестов не дошло: ошибка компиляции, таймаут или сломанный файл тестов
+		log.Printf("❌ No unit test results for task %s: %v", task.ID, err)
+		verdict := run.Verdict
+		if verdict == "" || verdict == models.VerdictOK {
+			verdict = models.VerdictCheckerError
+		}
+		return models.CheckResponse{
+			Output:      run.Output,
+			Message:     "❌ Тесты не запустились",
+			Verdict:     verdict,
+			Stats:       run.Stats,
+			ImageDigest: run.ImageDigest,
+			Backend:     run.Backend,
+		}
+	}
+
+	verdict := unittest.Verdict(results)
+	switch run.Verdict {
+	case models.VerdictTimeLimit, models.VerdictMemoryLimit, models.VerdictOutputLimit, models.VerdictCancelled:
+		// Отчет неполный, важнее то, что прервало запуск
+		verdict = run.Verdict
+	}
+
+	passed := 0
+	for _, result := range results {
+		if result.Verdict == models.VerdictOK {
+			passed++
+		}
+	}
+	message := fmt.Sprintf("✅ Пройдено тестов: %d из %d", passed, len(results))
+	if verdict != models.VerdictOK {
+		message = fmt.Sprintf("❌ Пройдено тестов: %d из %d", passed, len(results))
+	}
+
+	return models.CheckResponse{
+		Success:     verdict == models.VerdictOK,
+		Passed:      verdict == models.VerdictOK,
+		Output:      run.Output,
+		Message:     message,
+		Verdict:     verdict,
+		Stats:       run.Stats,
+		ImageDigest: run.ImageDigest,
+		Backend:     run.Backend,
+		Tests:       results,
+	}
+}
@@ -0,0 +1,464 @@
+package harness
+
+import (
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Обвязки по языкам: программа с вызовами функции для всех тестов и
+// заготовка для редактора. Неизвестный номер теста - выход с кодом 2
+
+type generator struct {
+	program func(sig models.FunctionSignature, code string, tests [][]interface{}) string
+	stub    func(sig models.FunctionSignature) string
+}
+
+var generators = map[string]generator{
+	"python":     {pythonProgram, pythonStub},
+	"javascript": {javascriptProgram, javascriptStub},
+	"java":       {javaProgram, javaStub},
+	"cpp":        {cppProgram, cppStub},
+	"go":         {goProgram, goStub},
+}
+
+const stubComment = "Ваш код здесь"
+
+// paramNames - имена параметров через запятую
+func paramNames(sig models.FunctionSignature) string {
+	names := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		names[i] = param.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// typedArgs - аргументы вызова в синтаксисе языка
+func typedArgs(sig models.FunctionSignature, args []interface{}, literal func(typ string, value interface{}) string) string {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = literal(sig.Params[i].Type, arg)
+	}
+	return strings.Join(values, ", ")
+}
+
+// Python
+
+func pythonProgram(sig models.FunctionSignature, code string, tests [][]interface{}) string {
+	return fmt.Sprintf(`%s
+
+
+import json as _judge_json
+
+_judge_tests = _judge_json.loads(%s)
+with open(%q) as _judge_file:
+    _judge_args = _judge_tests[int(_judge_file.read())]
+print(_judge_json.dumps(%s(*_judge_args), ensure_ascii=False, separators=(",", ":")))
+`, code, strconv.Quote(jsonLiteral(tests)), TestFile, sig.Name)
+}
+
+func pythonStub(sig models.FunctionSignature) string {
+	return fmt.Sprintf("def %s(%s):\n    # %s\n    pass\n", sig.Name, paramNames(sig), stubComment)
+}
+
+// JavaScript
+
+func javascriptProgram(sig models.FunctionSignature, code string, tests [][]interface{}) string {
+	return fmt.Sprintf(`%s
+
+const __judgeTests = %s;
+const __judgeArgs = __judgeTests[Number(require("fs").readFileSync(%q, "utf8"))];
+console.log(JSON.stringify(%s(...__judgeArgs)));
+`, code, jsonLiteral(tests), TestFile, sig.Name)
+}
+
+func javascriptStub(sig models.FunctionSignature) string {
+	return fmt.Sprintf("function %s(%s) {\n    // %s\n}\n", sig.Name, paramNames(sig), stubComment)
+}
+
+// Java: функция становится методом класса Main, импорты ученика поднимаются наверх
+
+var javaTypes = map[string]string{"int": "int", "long": "long", "float": "double", "string": "String", "bool": "boolean"}
+
+func javaType(typ string) string {
+	if isArray(typ) {
+		return javaTypes[elemType(typ)] + "[]"
+	}
+	return javaTypes[typ]
+}
+
+func javaLiteral(typ string, value interface{}) string {
+	if isArray(typ) {
+		items := value.([]interface{})
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = javaLiteral(elemType(typ), item)
+		}
+		return fmt.Sprintf("new %s{%s}", javaType(typ), strings.Join(values, ", "))
+	}
+	switch typ {
+	case "long":
+		return fmt.Sprintf("%sL", value)
+	case "string":
+		return quoteC(value.(string))
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func javaProgram(sig models.FunctionSignature, code string, tests [][]interface{}) string {
+	var imports, body []string
+	for _, line := range strings.Split(code, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "import ") {
+			imports = append(imports, line)
+		} else {
+			body = append(body, line)
+		}
+	}
+
+	// Каждый тест в своем методе: у метода Java предел 64 КБ байткода
+	var cases, methods strings.Builder
+	for i, args := range tests {
+		fmt.Fprintf(&cases, "            case %d: judgeTest%d(); break;\n", i, i)
+		fmt.Fprintf(&methods, "\n    static void judgeTest%d() {\n        System.out.println(JudgeHarness.serialize(new Main().%s(%s)));\n    }\n",
+			i, sig.Name, typedArgs(sig, args, javaLiteral))
+	}
+
+	return fmt.Sprintf(`%s
+import java.util.*;
+
+public class Main {
+%s
+
+    public static void main(String[] args) throws Exception {
+        String judgeTest = new String(java.nio.file.Files.readAllBytes(java.nio.file.Paths.get(%s))).trim();
+        switch (Integer.parseInt(judgeTest)) {
+%s            default:
+                System.err.println("unknown test " + judgeTest);
+                System.exit(2);
+        }
+    }
+%s}
+
+class JudgeHarness {
+    static String serialize(long v) { return Long.toString(v); }
+    static String serialize(double v) { return Double.toString(v); }
+    static String serialize(boolean v) { return Boolean.toString(v); }
+
+    static String serialize(String v) {
+        if (v == null) return "null";
+        StringBuilder b = new StringBuilder("\"");
+        for (char c : v.toCharArray()) {
+            switch (c) {
+                case '"': b.append("\\\""); break;
+                case '\\': b.append("\\\\"); break;
+                case '\n': b.append("\\n"); break;
+                case '\r': b.append("\\r"); break;
+                case '\t': b.append("\\t"); break;
+                case '\b': b.append("\\b"); break;
+                case '\f': b.append("\\f"); break;
+                default:
+                    if (c < 0x20) b.append(String.format("\\u%%04x", (int) c));
+                    else b.append(c);
+            }
+        }
+        return b.append('"').toString();
+    }
+
+    static String serialize(int[] v) { return v == null ? "null" : serialize(Arrays.stream(v).boxed().toArray()); }
+    static String serialize(long[] v) { return v == null ? "null" : serialize(Arrays.stream(v).boxed().toArray()); }
+    static String serialize(double[] v) { return v == null ? "null" : serialize(Arrays.stream(v).boxed().toArray()); }
+    static String serialize(boolean[] v) {
+        if (v == null) return "null";
+        Object[] items = new Object[v.length];
+        for (int i = 0; i < v.length; i++) items[i] = v[i];
+        return serialize(items);
+    }
+    static String serialize(List<?> v) { return v == null ? "null" : serialize(v.toArray()); }
+
+    static String serialize(Object[] v) {
+        if (v == null) return "null";
+        StringJoiner joiner = new StringJoiner(",", "[", "]");
+        for (Object item : v) joiner.add(serializeObject(item));
+        return joiner.toString();
+    }
+
+    static String serializeObject(Object v) {
+        if (v instanceof String) return serialize((String) v);
+        if (v instanceof Double || v instanceof Float) return serialize(((Number) v).doubleValue());
+        if (v instanceof Number) return serialize(((Number) v).longValue());
+        if (v instanceof Boolean) return serialize((boolean) (Boolean) v);
+        return String.valueOf(v);
+    }
+}
+`, strings.Join(imports, "\n"), strings.Join(body, "\n"), quoteC(TestFile), cases.String(), methods.String())
+}
+
+func javaStub(sig models.FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = javaType(param.Type) + " " + param.Name
+	}
+	zero := map[string]string{"int": "0", "long": "0", "float": "0.0", "string": `""`, "bool": "false"}[sig.Returns]
+	if isArray(sig.Returns) {
+		zero = fmt.Sprintf("new %s[0]", javaTypes[elemType(sig.Returns)])
+	}
+	return fmt.Sprintf("public static %s %s(%s) {\n    // %s\n    return %s;\n}\n",
+		javaType(sig.Returns), sig.Name, strings.Join(params, ", "), stubComment, zero)
+}
+
+// C++
+
+var cppTypes = map[string]string{"int": "int", "long": "long long", "float": "double", "string": "string", "bool": "bool"}
+
+func cppType(typ string) string {
+	if isArray(typ) {
+		return "vector<" + cppTypes[elemType(typ)] + ">"
+	}
+	return cppTypes[typ]
+}
+
+func cppLiteral(typ string, value interface{}) string {
+	if isArray(typ) {
+		items := value.([]interface{})
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = cppLiteral(elemType(typ), item)
+		}
+		return fmt.Sprintf("%s{%s}", cppType(typ), strings.Join(values, ", "))
+	}
+	switch typ {
+	case "long":
+		return fmt.Sprintf("%sLL", value)
+	case "string":
+		return "string(" + quoteC(value.(string)) + ")"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func cppProgram(sig models.FunctionSignature, code string, tests [][]interface{}) string {
+	var cases strings.Builder
+	for i, args := range tests {
+		fmt.Fprintf(&cases, "        case %d: cout << judge_harness::serialize(%s(%s)) << endl; break;\n",
+			i, sig.Name, typedArgs(sig, args, cppLiteral))
+	}
+
+	return fmt.Sprintf(`#include <cstdio>
+#include <fstream>
+#include <iomanip>
+#include <iostream>
+#include <sstream>
+#include <string>
+#include <vector>
+using namespace std;
+
+%s
+
+namespace judge_harness {
+string serialize(int v) { return to_string(v); }
+string serialize(long v) { return to_string(v); }
+string serialize(long long v) { return to_string(v); }
+string serialize(double v) {
+    ostringstream out;
+    out << setprecision(15) << v;
+    return out.str();
+}
+string serialize(bool v) { return v ? "true" : "false"; }
+string serialize(const string& v) {
+    string out = "\"";
+    for (char c : v) {
+        switch (c) {
+            case '"': out += "\\\""; break;
+            case '\\': out += "\\\\"; break;
+            case '\n': out += "\\n"; break;
+            case '\r': out += "\\r"; break;
+            case '\t': out += "\\t"; break;
+            case '\b': out += "\\b"; break;
+            case '\f': out += "\\f"; break;
+            default:
+                if ((unsigned char) c < 0x20) {
+                    char buf[8];
+                    snprintf(buf, sizeof(buf), "\\u%%04x", c);
+                    out += buf;
+                } else {
+                    out += c;
+                }
+        }
+    }
+    return out + "\"";
+}
+string serialize(const char* v) { return serialize(string(v)); }
+template <typename T>
+string serialize(const vector<T>& v) {
+    string out = "[";
+    for (size_t i = 0; i < v.size(); i++) {
+        if (i > 0) out += ",";
+        out += serialize(static_cast<T>(v[i]));
+    }
+    return out + "]";
+}
+}
+
+int main() {
+    int judge_test = -1;
+    ifstream(%s) >> judge_test;
+    switch (judge_test) {
+%s        default:
+            cerr << "unknown test " << judge_test << endl;
+            return 2;
+    }
+    return 0;
+}
+`, code, quoteC(TestFile), cases.String())
+}
+
+func cppStub(sig models.FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = cppType(param.Type) + " " + param.Name
+	}
+	zero := map[string]string{"int": "0", "long": "0", "float": "0.0", "string": `""`, "bool": "false"}[sig.Returns]
+	if isArray(sig.Returns) {
+		zero = "{}"
+	}
+	return fmt.Sprintf("%s %s(%s) {\n    // %s\n    return %s;\n}\n",
+		cppType(sig.Returns), sig.Name, strings.Join(params, ", "), stubComment, zero)
+}
+
+// Go: если ученик написал package main, импорты обвязки встают сразу после него
+
+var goTypes = map[string]string{"int": "int", "long": "int64", "float": "float64", "string": "string", "bool": "bool"}
+
+func goType(typ string) string {
+	if isArray(typ) {
+		return "[]" + goTypes[elemType(typ)]
+	}
+	return goTypes[typ]
+}
+
+func goLiteral(typ string, value interface{}) string {
+	if isArray(typ) {
+		items := value.([]interface{})
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = goLiteral(elemType(typ), item)
+		}
+		return fmt.Sprintf("%s{%s}", goType(typ), strings.Join(values, ", "))
+	}
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+const goImports = `import (
+	judgefmt "fmt"
+	judgeos "os"
+	judgestrings "strings"
+)`
+
+func goProgram(sig models.FunctionSignature, code string, tests [][]interface{}) string {
+	header := "package main\n\n" + goImports + "\n\n"
+	if trimmed := strings.TrimSpace(code); strings.HasPrefix(trimmed, "package ") {
+		packageLine, rest, _ := strings.Cut(trimmed, "\n")
+		header = packageLine + "\n\n" + goImports + "\n"
+		code = rest
+	}
+
+	var cases strings.Builder
+	for i, args := range tests {
+		fmt.Fprintf(&cases, "\tcase %d:\n\t\tjudgefmt.Println(judgeSerialize(%s(%s)))\n",
+			i, sig.Name, typedArgs(sig, args, goLiteral))
+	}
+
+	return header + code + fmt.Sprintf(`
+
+func main() {
+	judgeData, _ := judgeos.ReadFile(%q)
+	judgeTest := -1
+	judgefmt.Sscan(string(judgeData), &judgeTest)
+	switch judgeTest {
+%s	default:
+		judgefmt.Fprintln(judgeos.Stderr, "unknown test", judgeTest)
+		judgeos.Exit(2)
+	}
+}
+
+func judgeSerialize(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return judgeQuote(v)
+	case []int:
+		return judgeJoin(v)
+	case []int64:
+		return judgeJoin(v)
+	case []float64:
+		return judgeJoin(v)
+	case []string:
+		return judgeJoin(v)
+	case []bool:
+		return judgeJoin(v)
+	default:
+		return judgefmt.Sprint(v)
+	}
+}
+
+func judgeJoin[T any](items []T) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = judgeSerialize(item)
+	}
+	return "[" + judgestrings.Join(parts, ",") + "]"
+}
+
+func judgeQuote(s string) string {
+	var b judgestrings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString("\\n")
+		case '\r':
+			b.WriteString("\\r")
+		case '\t':
+			b.WriteString("\\t")
+		case '\b':
+			b.WriteString("\\b")
+		case '\f':
+			b.WriteString("\\f")
+		default:
+			if r < 0x20 {
+				judgefmt.Fprintf(&b, "\\u%%04x", r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+`, TestFile, cases.String())
+}
+
+func goStub(sig models.FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = param.Name + " " + goType(param.Type)
+	}
+	zero := map[string]string{"int": "0", "long": "0", "float": "0", "string": `""`, "bool": "false"}[sig.Returns]
+	if isArray(sig.Returns) {
+		zero = "nil"
+	}
+	return fmt.Sprintf("package main\n\nfunc %s(%s) %s {\n\t// %s\n\treturn %s\n}\n",
+		sig.Name, strings.Join(params, ", "), goType(sig.Returns), stubComment, zero)
+}
@@ -0,0 +1,217 @@
+package harness
+
+import (
+	"backend/internal/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Обвязка для задач, где ученик пишет только функцию. Код ученика
+// дополняется программой со всеми тестами задачи: она читает номер теста
+// из файла TestFile, вызывает функцию с его аргументами и печатает
+// результат одной строкой в формате JSON:
+// числа как есть, строки в кавычках, bool - true/false, массивы - [1,2,3].
+// В этом же формате записывается ожидаемый вывод тестов.
+//
+// Аргументы теста - значения JSON через запятую: `2, 3` или `[1,2], "abc"`.
+//
+// Типы: int, long, float, string, bool и массивы из них (int[], string[]...)
+
+// Scalar типы параметров и результата
+var scalarTypes = map[string]bool{
+	"int":    true,
+	"long":   true,
+	"float":  true,
+	"string": true,
+	"bool":   true,
+}
+
+// Supported - есть ли обвязка для языка (по id из реестра)
+func Supported(language string) bool {
+	_, ok := generators[language]
+	return ok
+}
+
+// TestFile - файл с номером теста (с нуля) в рабочем каталоге программы.
+// Программа одна на все тесты, поэтому собирается один раз за проверку
+const TestFile = "judge_test.txt"
+
+// Generate собирает программу: код ученика и вызовы функции sig
+// с аргументами каждого из inputs
+func Generate(language string, sig models.FunctionSignature, code string, inputs []string) (string, error) {
+	gen, ok := generators[language]
+	if !ok {
+		return "", fmt.Errorf("function tasks are not supported for %s", language)
+	}
+	if err := Validate(sig); err != nil {
+		return "", err
+	}
+	tests := make([][]interface{}, len(inputs))
+	for i, input := range inputs {
+		args, err := ParseArgs(sig, input)
+		if err != nil {
+			return "", fmt.Errorf("test %d: %w", i+1, err)
+		}
+		tests[i] = args
+	}
+	return gen.program(sig, code, tests), nil
+}
+
+// SelectTest - файлы запуска, выбирающие тест с номером index (с нуля)
+func SelectTest(index int) map[string]string {
+	return map[string]string{TestFile: strconv.Itoa(index)}
+}
+
+// Stub - заготовка функции для редактора. Пустая строка - язык не поддерживается
+func Stub(language string, sig models.FunctionSignature) string {
+	gen, ok := generators[language]
+	if !ok || Validate(sig) != nil {
+		return ""
+	}
+	return gen.stub(sig)
+}
+
+// DefaultMode - режим чекера по умолчанию: дробные результаты сравниваем с погрешностью
+func DefaultMode(sig models.FunctionSignature) string {
+	if elemType(sig.Returns) == "float" {
+		return "float"
+	}
+	return "exact"
+}
+
+// Validate проверяет имя функции и типы
+func Validate(sig models.FunctionSignature) error {
+	if !isIdentifier(sig.Name) {
+		return fmt.Errorf("invalid function name: %q", sig.Name)
+	}
+	if !validType(sig.Returns) {
+		return fmt.Errorf("unsupported return type: %q", sig.Returns)
+	}
+	for _, param := range sig.Params {
+		if !isIdentifier(param.Name) {
+			return fmt.Errorf("invalid parameter name: %q", param.Name)
+		}
+		if !validType(param.Type) {
+			return fmt.Errorf("unsupported type of %s: %q", param.Name, param.Type)
+		}
+	}
+	return nil
+}
+
+// ParseArgs разбирает аргументы теста и проверяет их по сигнатуре
+func ParseArgs(sig models.FunctionSignature, input string) ([]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader("[" + input + "]"))
+	decoder.UseNumber()
+
+	var args []interface{}
+	if err := decoder.Decode(&args); err != nil {
+		return nil, fmt.Errorf("invalid test arguments %q: %v", input, err)
+	}
+	if len(args) != len(sig.Params) {
+		return nil, fmt.Errorf("%s expects %d arguments, test has %d", sig.Name, len(sig.Params), len(args))
+	}
+	for i, param := range sig.Params {
+		if err := checkValue(param.Type, args[i]); err != nil {
+			return nil, fmt.Errorf("argument %s: %v", param.Name, err)
+		}
+	}
+	return args, nil
+}
+
+func checkValue(typ string, value interface{}) error {
+	if isArray(typ) {
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected %s, got %v", typ, value)
+		}
+		for _, item := range items {
+			if err := checkValue(elemType(typ), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ok := false
+	switch typ {
+	case "int", "long":
+		if n, isNumber := value.(json.Number); isNumber {
+			_, err := strconv.ParseInt(string(n), 10, 64)
+			ok = err == nil
+		}
+	case "float":
+		_, ok = value.(json.Number)
+	case "string":
+		_, ok = value.(string)
+	case "bool":
+		_, ok = value.(bool)
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, got %v", typ, value)
+	}
+	return nil
+}
+
+func validType(typ string) bool {
+	return scalarTypes[elemType(typ)] && strings.Count(typ, "[]") <= 1
+}
+
+func isArray(typ string) bool {
+	return strings.HasSuffix(typ, "[]")
+}
+
+// elemType - тип элемента массива (для скаляра - сам тип)
+func elemType(typ string) string {
+	return strings.TrimSuffix(typ, "[]")
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonLiteral - значение в виде JSON (для Python и JavaScript)
+func jsonLiteral(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSpace(buf.String())
+}
+
+// quoteC - строковый литерал для Java и C++. Управляющие символы - восьмеричными
+// escape-последовательностями, они одинаково понимаются обоими языками
+func quoteC(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
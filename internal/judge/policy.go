@@ -0,0 +1,141 @@
+package judge
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Политика выбора исполнителя. Локальный исполнитель запускает код на
+// хосте (в лучшем случае в песочнице), поэтому уходить на него можно
+// только там, где это явно разрешено: политикой и списком языков
+
+const (
+	PolicyDocker         = "docker"          // только Docker, без запасного варианта
+	PolicyLocal          = "local"           // только локальный исполнитель
+	PolicyDockerFallback = "docker-fallback" // Docker, при сбое инфраструктуры - локально, только в песочнице
+)
+
+// Policy - какой исполнитель можно использовать
+type Policy struct {
+	Mode string
+	// LocalLanguages - языки, которые можно запускать локально, пусто - все
+	LocalLanguages []string
+	// BreakerThreshold - сколько сбоев Docker подряд открывают breaker
+	BreakerThreshold int
+	// BreakerCooldown - сколько breaker открыт до пробного запуска
+	BreakerCooldown time.Duration
+}
+
+// ParsePolicy проверяет режим политики
+func ParsePolicy(mode string) (string, error) {
+	switch mode {
+	case PolicyDocker, PolicyLocal, PolicyDockerFallback:
+		return mode, nil
+	case "":
+		return PolicyDockerFallback, nil
+	}
+	return "", fmt.Errorf("unknown executor policy %q (use %s, %s or %s)", mode, PolicyDocker, PolicyLocal, PolicyDockerFallback)
+}
+
+// Состояния breaker
+const (
+	BreakerClosed   = "closed"    // Docker работает
+	BreakerOpen     = "open"      // Docker сбоит, запуски в него не идут
+	BreakerHalfOpen = "half-open" // идет пробный запуск
+)
+
+// Breaker считает сбои инфраструктуры Docker. Ошибки кода пользователя
+// (компиляция, падение, лимиты) сбоями не считаются: Docker с ними
+// справился. После threshold сбоев подряд breaker открывается на
+// cooldown, затем пропускает один пробный запуск
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerStatus - состояние breaker для /api/health
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// NewBreaker создает breaker. threshold <= 0 - breaker никогда не открывается
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// Allow - можно ли сейчас запускать в Docker. В состоянии half-open
+// пропускает только один пробный запуск
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Success отмечает запуск, который Docker довел до конца
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure отмечает сбой инфраструктуры. true - breaker только что открылся
+func (b *Breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.threshold > 0 && b.failures >= b.threshold && b.state == BreakerClosed) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// Release отпускает пробный запуск, который ничего не сказал о Docker
+// (клиент ушел или у языка нет образа)
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Status возвращает состояние breaker
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,415 @@
+package judge
+
+import (
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Очередь заданий для воркеров. Живет в процессе API: воркер
+// регистрируется, берет задания в аренду (lease), шлет heartbeat и
+// возвращает результат. Если воркер пропал (нет heartbeat дольше
+// WorkerTimeout) или аренда истекла, задание возвращается в начало
+// очереди и достается другому воркеру, но не больше MaxAttempts раз
+
+var (
+	ErrNoWorkers     = errors.New("no judge workers available")
+	ErrUnknownWorker = errors.New("unknown judge worker")
+	ErrStaleLease    = errors.New("job is not leased to this worker")
+)
+
+// WorkerInfo - что воркер сообщает о себе при регистрации
+type WorkerInfo struct {
+	Name string `json:"name"`
+	// Slots - сколько заданий воркер выполняет одновременно
+	Slots int `json:"slots"`
+	// Languages - какие языки воркер умеет запускать, пусто - все
+	Languages []string `json:"languages,omitempty"`
+	Docker    bool     `json:"docker"`
+}
+
+// Assignment - задание, выданное воркеру в аренду
+type Assignment struct {
+	ID  string       `json:"id"`
+	Job executor.Job `json:"job"`
+	// Deadline - когда аренда истечет и задание отдадут другому воркеру
+	Deadline time.Time `json:"deadline"`
+}
+
+// QueueOptions - таймауты очереди
+type QueueOptions struct {
+	WorkerTimeout time.Duration // воркер без heartbeat считается упавшим
+	LeaseTimeout  time.Duration // сколько воркер может выполнять одно задание
+	LeaseWait     time.Duration // сколько Lease ждет задание, если очередь пуста
+	MaxAttempts   int           // сколько раз задание выдается воркерам
+	// Languages - реестр для сопоставления языков заданий и воркеров по ID,
+	// чтобы алиасы (py, c++, js) находили своих воркеров. nil - как есть
+	Languages *languages.Registry
+}
+
+// WorkerStatus - воркер для /api/judge/status
+type WorkerStatus struct {
+	ID       string     `json:"id"`
+	Info     WorkerInfo `json:"info"`
+	LastSeen time.Time  `json:"last_seen"`
+	Running  int        `json:"running"`
+}
+
+// QueueStatus - состояние очереди
+type QueueStatus struct {
+	Pending int            `json:"pending"`
+	Workers []WorkerStatus `json:"workers"`
+}
+
+type queuedJob struct {
+	id       string
+	job      executor.Job
+	attempts int
+	worker   string // кому выдано, пусто - ждет в очереди
+	deadline time.Time
+	done     chan models.ExecutionResponse
+}
+
+type workerState struct {
+	id        string
+	info      WorkerInfo
+	languages map[string]bool
+	lastSeen  time.Time
+	leases    map[string]*queuedJob
+	cancelled []string // задания, которые больше не нужны: сообщаются в heartbeat
+}
+
+// Queue - очередь заданий и зарегистрированные воркеры
+type Queue struct {
+	opts QueueOptions
+
+	mu      sync.Mutex
+	pending []*queuedJob
+	jobs    map[string]*queuedJob
+	workers map[string]*workerState
+	wake    chan struct{} // закрывается, когда в очереди появилось задание
+}
+
+// NewQueue создает очередь. Упавших воркеров и истекшие аренды
+// проверяет Run
+func NewQueue(opts QueueOptions) *Queue {
+	return &Queue{
+		opts:    opts,
+		jobs:    make(map[string]*queuedJob),
+		workers: make(map[string]*workerState),
+		wake:    make(chan struct{}),
+	}
+}
+
+// Run возвращает в очередь задания упавших воркеров и истекших аренд,
+// пока не отменен ctx
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.WorkerTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			q.reap(now)
+		}
+	}
+}
+
+// Register добавляет воркера и возвращает его ID
+func (q *Queue) Register(info WorkerInfo) string {
+	if info.Slots <= 0 {
+		info.Slots = 1
+	}
+	worker := &workerState{
+		id:        newID(),
+		info:      info,
+		languages: make(map[string]bool, len(info.Languages)),
+		lastSeen:  time.Now(),
+		leases:    make(map[string]*queuedJob),
+	}
+	for _, lang := range info.Languages {
+		worker.languages[q.canonical(lang)] = true
+	}
+
+	q.mu.Lock()
+	q.workers[worker.id] = worker
+	q.mu.Unlock()
+
+	log.Printf("👷 Judge worker %s registered: %s, %d slots", worker.id[:8], info.Name, info.Slots)
+	return worker.id
+}
+
+// Heartbeat отмечает, что воркер жив. Возвращает ID его заданий,
+// которые больше не нужны (клиент ушел) - их надо остановить
+func (q *Queue) Heartbeat(workerID string) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	worker, ok := q.workers[workerID]
+	if !ok {
+		return nil, ErrUnknownWorker
+	}
+	worker.lastSeen = time.Now()
+	cancelled := worker.cancelled
+	worker.cancelled = nil
+	return cancelled, nil
+}
+
+// Lease выдает воркеру задание из очереди. Если подходящих нет, ждет
+// до LeaseWait и возвращает nil
+func (q *Queue) Lease(ctx context.Context, workerID string) (*Assignment, error) {
+	timer := time.NewTimer(q.opts.LeaseWait)
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		worker, ok := q.workers[workerID]
+		if !ok {
+			q.mu.Unlock()
+			return nil, ErrUnknownWorker
+		}
+		worker.lastSeen = time.Now()
+
+		for i, queued := range q.pending {
+			if !worker.accepts(queued.job.Language) {
+				continue
+			}
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			queued.worker = workerID
+			queued.attempts++
+			queued.deadline = time.Now().Add(q.opts.LeaseTimeout)
+			worker.leases[queued.id] = queued
+			q.mu.Unlock()
+
+			log.Printf("📤 Job %s leased to %s (attempt %d)", queued.id[:8], worker.info.Name, queued.attempts)
+			return &Assignment{ID: queued.id, Job: queued.job, Deadline: queued.deadline}, nil
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Complete принимает результат задания. ErrStaleLease - аренда уже
+// истекла и задание отдано другому воркеру или больше не нужно
+func (q *Queue) Complete(workerID, jobID string, result models.ExecutionResponse) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	worker, ok := q.workers[workerID]
+	if !ok {
+		return ErrUnknownWorker
+	}
+	worker.lastSeen = time.Now()
+	queued, ok := worker.leases[jobID]
+	if !ok {
+		return ErrStaleLease
+	}
+	delete(worker.leases, jobID)
+	delete(q.jobs, jobID)
+	queued.done <- result
+	return nil
+}
+
+// Release возвращает выданное задание в начало очереди без траты попытки
+// (воркер его так и не получил)
+func (q *Queue) Release(workerID, jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	worker, ok := q.workers[workerID]
+	if !ok {
+		return
+	}
+	queued, ok := worker.leases[jobID]
+	if !ok {
+		return
+	}
+	delete(worker.leases, jobID)
+	queued.worker = ""
+	queued.attempts--
+	q.pending = append([]*queuedJob{queued}, q.pending...)
+	q.notify()
+}
+
+// Submit ставит задание в очередь и ждет результат. ErrNoWorkers -
+// нет живого воркера для языка задания. Отмена ctx снимает задание,
+// а если оно уже выполняется - воркер остановит его после heartbeat
+func (q *Queue) Submit(ctx context.Context, job executor.Job) (models.ExecutionResponse, error) {
+	job.Language = q.canonical(job.Language)
+	queued := &queuedJob{
+		id:   newID(),
+		job:  job,
+		done: make(chan models.ExecutionResponse, 1),
+	}
+
+	q.mu.Lock()
+	if !q.hasWorkerFor(job.Language) {
+		q.mu.Unlock()
+		return models.ExecutionResponse{}, ErrNoWorkers
+	}
+	q.jobs[queued.id] = queued
+	q.pending = append(q.pending, queued)
+	q.notify()
+	q.mu.Unlock()
+
+	select {
+	case result := <-queued.done:
+		return result, nil
+	case <-ctx.Done():
+		q.cancel(queued.id)
+		return models.ExecutionResponse{}, ctx.Err()
+	}
+}
+
+// Status возвращает длину очереди и воркеров
+func (q *Queue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := QueueStatus{Pending: len(q.pending), Workers: []WorkerStatus{}}
+	for _, worker := range q.workers {
+		status.Workers = append(status.Workers, WorkerStatus{
+			ID:       worker.id,
+			Info:     worker.info,
+			LastSeen: worker.lastSeen,
+			Running:  len(worker.leases),
+		})
+	}
+	return status
+}
+
+// cancel снимает задание из очереди или у воркера
+func (q *Queue) cancel(jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued, ok := q.jobs[jobID]
+	if !ok {
+		return
+	}
+	delete(q.jobs, jobID)
+	if queued.worker == "" {
+		q.removePending(queued)
+		return
+	}
+	if worker, ok := q.workers[queued.worker]; ok {
+		delete(worker.leases, jobID)
+		worker.cancelled = append(worker.cancelled, jobID)
+	}
+}
+
+// reap удаляет воркеров без heartbeat и забирает задания с истекшей арендой
+func (q *Queue) reap(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, worker := range q.workers {
+		if now.Sub(worker.lastSeen) > q.opts.WorkerTimeout {
+			log.Printf("💀 Judge worker %s (%s) is gone, re-queueing %d jobs", id[:8], worker.info.Name, len(worker.leases))
+			delete(q.workers, id)
+			for _, queued := range worker.leases {
+				q.requeue(queued)
+			}
+			continue
+		}
+		for jobID, queued := range worker.leases {
+			if now.After(queued.deadline) {
+				log.Printf("⌛ Lease of job %s on %s expired", jobID[:8], worker.info.Name)
+				delete(worker.leases, jobID)
+				// Воркер еще жив - пусть остановит задание
+				worker.cancelled = append(worker.cancelled, jobID)
+				q.requeue(queued)
+			}
+		}
+	}
+
+	// Без воркеров для языка задания в очереди никто не заберет
+	for i := 0; i < len(q.pending); i++ {
+		queued := q.pending[i]
+		if !q.hasWorkerFor(queued.job.Language) {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			i--
+			q.fail(queued, "Нет доступных проверяющих серверов")
+		}
+	}
+}
+
+// requeue возвращает задание в начало очереди или завершает его ошибкой,
+// если попытки кончились. Вызывается под mu
+func (q *Queue) requeue(queued *queuedJob) {
+	queued.worker = ""
+	if queued.attempts >= q.opts.MaxAttempts {
+		q.fail(queued, "Проверяющие серверы не смогли выполнить задание")
+		return
+	}
+	q.pending = append([]*queuedJob{queued}, q.pending...)
+	q.notify()
+}
+
+// fail завершает задание ошибкой. Вызывается под mu
+func (q *Queue) fail(queued *queuedJob, message string) {
+	log.Printf("❌ Job %s failed after %d attempts: %s", queued.id[:8], queued.attempts, message)
+	delete(q.jobs, queued.id)
+	queued.done <- models.ExecutionResponse{Success: false, Message: message}
+}
+
+func (q *Queue) removePending(queued *queuedJob) {
+	for i, item := range q.pending {
+		if item == queued {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// hasWorkerFor - есть ли живой воркер для языка. Вызывается под mu
+func (q *Queue) hasWorkerFor(language string) bool {
+	for _, worker := range q.workers {
+		if worker.accepts(language) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify будит ждущие Lease. Вызывается под mu
+func (q *Queue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// canonical - ID языка по имени или алиасу. Неизвестные имена остаются как есть
+func (q *Queue) canonical(language string) string {
+	if q.opts.Languages != nil {
+		if lang, ok := q.opts.Languages.Lookup(language); ok {
+			return lang.ID
+		}
+	}
+	return language
+}
+
+func (w *workerState) accepts(language string) bool {
+	return len(w.languages) == 0 || w.languages[language]
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
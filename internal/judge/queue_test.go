@@ -0,0 +1,233 @@
+package judge
+
+import (
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type submission struct {
+	result models.ExecutionResponse
+	err    error
+}
+
+func newTestQueue(opts QueueOptions) *Queue {
+	if opts.WorkerTimeout == 0 {
+		opts.WorkerTimeout = time.Hour
+	}
+	if opts.LeaseTimeout == 0 {
+		opts.LeaseTimeout = time.Minute
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 3
+	}
+	opts.LeaseWait = time.Second
+	return NewQueue(opts)
+}
+
+func submit(q *Queue, job executor.Job) <-chan submission {
+	done := make(chan submission, 1)
+	go func() {
+		result, err := q.Submit(context.Background(), job)
+		done <- submission{result, err}
+	}()
+	return done
+}
+
+func lease(t *testing.T, q *Queue, workerID string) *Assignment {
+	t.Helper()
+	assignment, err := q.Lease(context.Background(), workerID)
+	if err != nil {
+		t.Fatalf("Lease: %v", err)
+	}
+	if assignment == nil {
+		t.Fatal("Lease returned no job")
+	}
+	return assignment
+}
+
+func wait(t *testing.T, done <-chan submission) submission {
+	t.Helper()
+	select {
+	case s := <-done:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("Submit did not return")
+		return submission{}
+	}
+}
+
+func TestQueueLeaseExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		expire      int    // сколько аренд истекает до удачной
+		wantMessage string // пусто - задание выполнено
+	}{
+		{name: "completes on first lease", maxAttempts: 3},
+		{name: "retried after expired leases", maxAttempts: 3, expire: 2},
+		{name: "fails after max attempts", maxAttempts: 2, expire: 2, wantMessage: "Проверяющие серверы не смогли выполнить задание"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(QueueOptions{MaxAttempts: tt.maxAttempts})
+			worker := q.Register(WorkerInfo{Name: "w"})
+			done := submit(q, executor.Job{Language: "python"})
+
+			var jobID string
+			for i := 0; i < tt.expire; i++ {
+				assignment := lease(t, q, worker)
+				if jobID != "" && assignment.ID != jobID {
+					t.Fatalf("attempt %d leased %s, want the same job %s", i+1, assignment.ID, jobID)
+				}
+				jobID = assignment.ID
+
+				q.reap(assignment.Deadline.Add(time.Second))
+				cancelled, err := q.Heartbeat(worker)
+				if err != nil {
+					t.Fatalf("Heartbeat: %v", err)
+				}
+				if !slices.Contains(cancelled, jobID) {
+					t.Errorf("Heartbeat cancelled %v, want %s", cancelled, jobID)
+				}
+				if err := q.Complete(worker, jobID, models.ExecutionResponse{Success: true}); !errors.Is(err, ErrStaleLease) {
+					t.Errorf("Complete after expiry = %v, want ErrStaleLease", err)
+				}
+			}
+
+			if tt.wantMessage == "" {
+				assignment := lease(t, q, worker)
+				if err := q.Complete(worker, assignment.ID, models.ExecutionResponse{Success: true, Output: "ok"}); err != nil {
+					t.Fatalf("Complete: %v", err)
+				}
+			}
+			got := wait(t, done)
+			if got.err != nil {
+				t.Fatalf("Submit: %v", got.err)
+			}
+			if tt.wantMessage == "" && (!got.result.Success || got.result.Output != "ok") {
+				t.Errorf("result = %+v, want the worker's result", got.result)
+			}
+			if tt.wantMessage != "" && (got.result.Success || got.result.Message != tt.wantMessage) {
+				t.Errorf("result = %+v, want failure %q", got.result, tt.wantMessage)
+			}
+			if status := q.Status(); status.Pending != 0 {
+				t.Errorf("%d jobs left in the queue", status.Pending)
+			}
+		})
+	}
+}
+
+func TestQueueWorkerGone(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacement bool   // есть ли живой воркер, которому отдать задание
+		wantMessage string // пусто - задание выполнено другим воркером
+	}{
+		{name: "re-queued to another worker", replacement: true},
+		{name: "fails without workers", wantMessage: "Нет доступных проверяющих серверов"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(QueueOptions{WorkerTimeout: 50 * time.Millisecond})
+			gone := q.Register(WorkerInfo{Name: "gone"})
+			done := submit(q, executor.Job{Language: "python"})
+			first := lease(t, q, gone)
+
+			time.Sleep(100 * time.Millisecond)
+			var alive string
+			if tt.replacement {
+				alive = q.Register(WorkerInfo{Name: "alive"})
+			}
+			q.reap(time.Now())
+
+			if _, err := q.Heartbeat(gone); !errors.Is(err, ErrUnknownWorker) {
+				t.Errorf("Heartbeat of a removed worker = %v, want ErrUnknownWorker", err)
+			}
+			if tt.replacement {
+				second := lease(t, q, alive)
+				if second.ID != first.ID {
+					t.Fatalf("leased %s, want re-queued job %s", second.ID, first.ID)
+				}
+				if err := q.Complete(alive, second.ID, models.ExecutionResponse{Success: true}); err != nil {
+					t.Fatalf("Complete: %v", err)
+				}
+			}
+
+			got := wait(t, done)
+			if got.err != nil {
+				t.Fatalf("Submit: %v", got.err)
+			}
+			if got.result.Message != tt.wantMessage || got.result.Success != (tt.wantMessage == "") {
+				t.Errorf("result = %+v, want message %q", got.result, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestQueueRelease(t *testing.T) {
+	q := newTestQueue(QueueOptions{MaxAttempts: 1})
+	worker := q.Register(WorkerInfo{Name: "w"})
+	done := submit(q, executor.Job{Language: "python"})
+
+	// Возврат без выполнения не тратит единственную попытку
+	first := lease(t, q, worker)
+	q.Release(worker, first.ID)
+	second := lease(t, q, worker)
+	if second.ID != first.ID {
+		t.Fatalf("leased %s, want released job %s", second.ID, first.ID)
+	}
+	if err := q.Complete(worker, second.ID, models.ExecutionResponse{Success: true}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if got := wait(t, done); got.err != nil || !got.result.Success {
+		t.Errorf("Submit = %+v, %v, want success", got.result, got.err)
+	}
+}
+
+func TestQueueLanguageAliases(t *testing.T) {
+	registry, err := languages.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		workerLangs  []string
+		jobLanguage  string
+		wantLanguage string // пусто - воркера для языка нет
+	}{
+		{name: "alias job, canonical worker", workerLangs: []string{"python"}, jobLanguage: "py", wantLanguage: "python"},
+		{name: "canonical job, alias worker", workerLangs: []string{"c++"}, jobLanguage: "cpp", wantLanguage: "cpp"},
+		{name: "alias job, alias worker", workerLangs: []string{"node"}, jobLanguage: "js", wantLanguage: "javascript"},
+		{name: "other language", workerLangs: []string{"python"}, jobLanguage: "js"},
+		{name: "worker for all languages", jobLanguage: "python3", wantLanguage: "python"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(QueueOptions{Languages: registry})
+			worker := q.Register(WorkerInfo{Name: "w", Languages: tt.workerLangs})
+
+			if tt.wantLanguage == "" {
+				if _, err := q.Submit(context.Background(), executor.Job{Language: tt.jobLanguage}); !errors.Is(err, ErrNoWorkers) {
+					t.Errorf("Submit = %v, want ErrNoWorkers", err)
+				}
+				return
+			}
+
+			done := submit(q, executor.Job{Language: tt.jobLanguage})
+			assignment := lease(t, q, worker)
+			if assignment.Job.Language != tt.wantLanguage {
+				t.Errorf("leased job language = %q, want %q", assignment.Job.Language, tt.wantLanguage)
+			}
+			if err := q.Complete(worker, assignment.ID, models.ExecutionResponse{Success: true}); err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			wait(t, done)
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package judge
+
+import (
+	"backend/internal/compilecache"
+	"backend/internal/config"
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/models"
+	"backend/internal/sandbox"
+	"backend/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Runner выполняет задания в этом процессе: в Docker или локальным
+// исполнителем, как разрешает политика. Им пользуются API без очереди
+// и воркеры (cmd/judge-worker)
+type Runner struct {
+	Docker *services.DockerService // nil - Docker недоступен
+	Local  *executor.LocalExecutor
+
+	languages *languages.Registry
+	policy    Policy
+	local     map[string]bool // языки, которые можно запускать локально, пусто - все
+	fallback  bool            // docker-fallback может уходить на локальный исполнитель
+	breaker   *Breaker
+	// toolchains - последняя проверка языков, nil - образы считаются скачанными
+	toolchains Toolchains
+}
+
+// Toolchains - результаты проверки языков (languages.Prober)
+type Toolchains interface {
+	Status(id string) (languages.Status, bool)
+}
+
+// RunnerStatus - политика и состояние Docker для /api/health
+type RunnerStatus struct {
+	Policy         string        `json:"policy"`
+	Docker         bool          `json:"docker"`
+	LocalLanguages []string      `json:"local_languages,omitempty"`
+	LocalFallback  bool          `json:"local_fallback"`
+	Breaker        BreakerStatus `json:"breaker"`
+}
+
+// NewRunner создает исполнители по конфигу. Ошибка - если песочницу или
+// Docker явно потребовали, а они недоступны: молча запускать код без них нельзя
+func NewRunner(cfg *config.Config, registry *languages.Registry) (*Runner, error) {
+	mode, err := ParsePolicy(cfg.Executor.Policy)
+	if err != nil {
+		return nil, err
+	}
+	runner := &Runner{
+		languages: registry,
+		policy: Policy{
+			Mode:             mode,
+			LocalLanguages:   cfg.Executor.LocalLanguages,
+			BreakerThreshold: cfg.Executor.BreakerThreshold,
+			BreakerCooldown:  cfg.Executor.BreakerCooldown,
+		},
+		local:   make(map[string]bool),
+		breaker: NewBreaker(cfg.Executor.BreakerThreshold, cfg.Executor.BreakerCooldown),
+	}
+	for _, name := range cfg.Executor.LocalLanguages {
+		lang, ok := registry.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown language in LOCAL_LANGUAGES: %s", name)
+		}
+		runner.local[lang.ID] = true
+	}
+
+	if mode == PolicyLocal {
+		log.Println("Executor policy is local, Docker is not used")
+	} else if docker, err := services.NewDockerService(registry); err != nil {
+		log.Printf("Warning: Docker service not available: %v", err)
+	} else {
+		docker.SetOutputLimit(cfg.OutputLimit)
+		docker.SetTmpSize(cfg.Docker.TmpSizeMB)
+		// Без профиля безопасности в Docker не запускаем
+		if err := docker.SetSecurity(cfg.Docker.Security); err != nil {
+			log.Printf("❌ Docker security profile: %v", err)
+		} else {
+			runner.Docker = docker
+		}
+	}
+	if runner.Docker == nil && mode == PolicyDocker {
+		return nil, fmt.Errorf("executor policy is %s but Docker is not available", mode)
+	}
+	log.Printf("⚙️ Executor policy: %s, Docker: %t", mode, runner.Docker != nil)
+
+	if cfg.Sandbox.Enabled {
+		sb, err := sandbox.New(cfg.Sandbox)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox is enabled but not available: %w", err)
+		}
+		runner.Local = executor.NewSandboxedLocalExecutor(registry, sb)
+	} else {
+		runner.Local = executor.NewLocalExecutor(registry)
+	}
+	runner.Local.SetOutputLimit(cfg.OutputLimit)
+	runner.Local.SetCacheDir(cfg.BuildCacheDir)
+
+	// Без песочницы запасной запуск шел бы прямо на хосте. Это допустимо
+	// только по явному EXECUTOR_POLICY=local, но не молча при сбое Docker
+	switch {
+	case mode == PolicyDockerFallback && cfg.Sandbox.Enabled:
+		runner.fallback = true
+	case mode == PolicyDockerFallback:
+		log.Println("🚨 Local fallback is DISABLED: SANDBOX_ENABLED=false, code is never run on the host when Docker fails. " +
+			"Set SANDBOX_ENABLED=true to allow the fallback or EXECUTOR_POLICY=local to run locally on purpose")
+	case mode == PolicyLocal && !cfg.Sandbox.Enabled:
+		log.Println("🚨 EXECUTOR_POLICY=local without sandbox: code runs directly on the host as the server user")
+	}
+
+	compileCache := openCompileCache(cfg)
+	runner.Local.SetCompileCache(compileCache)
+	if runner.Docker != nil {
+		runner.Docker.SetCompileCache(compileCache)
+	}
+	return runner, nil
+}
+
+// openCompileCache открывает кэш собранных программ, общий для
+// локального исполнителя и Docker. nil - кэш выключен или недоступен
+func openCompileCache(cfg *config.Config) *compilecache.Cache {
+	if cfg.CompileCache.SizeMB <= 0 {
+		return nil
+	}
+	cache, err := compilecache.New(cfg.CompileCache.Dir, int64(cfg.CompileCache.SizeMB)*1024*1024)
+	if err != nil {
+		log.Printf("Warning: compile cache is disabled: %v", err)
+		return nil
+	}
+	stats := cache.Stats()
+	log.Printf("♻️ Compile cache: %s, %d entries, %d MB of %d MB",
+		cfg.CompileCache.Dir, stats.Entries, stats.Bytes/1024/1024, cfg.CompileCache.SizeMB)
+	return cache
+}
+
+// SetToolchains подключает проверку языков: язык, образа которого нет,
+// в Docker не отправляется
+func (r *Runner) SetToolchains(toolchains Toolchains) {
+	r.toolchains = toolchains
+}
+
+// Status возвращает политику и состояние breaker
+func (r *Runner) Status() RunnerStatus {
+	return RunnerStatus{
+		Policy:         r.policy.Mode,
+		Docker:         r.Docker != nil,
+		LocalLanguages: r.policy.LocalLanguages,
+		LocalFallback:  r.fallback,
+		Breaker:        r.breaker.Status(),
+	}
+}
+
+// Run выполняет задание исполнителем, который выбирает политика
+func (r *Runner) Run(ctx context.Context, job executor.Job) models.ExecutionResponse {
+	// Ошибка в файлах задания - ошибка пользователя, а не Docker
+	if err := job.Validate(); err != nil {
+		return models.ExecutionResponse{Success: false, Message: err.Error()}
+	}
+
+	if r.useDocker(job) {
+		log.Println("🐳 Attempting Docker execution...")
+		result, err := r.Docker.ExecuteCode(ctx, job)
+		if err == nil {
+			r.breaker.Success()
+			log.Printf("✅ Docker execution successful")
+			message := "Code executed successfully via Docker"
+			if !result.Success {
+				message = "Code execution failed in Docker"
+			}
+			return models.ExecutionResponse{
+				Success:     result.Success,
+				Message:     message,
+				Output:      result.Output,
+				Verdict:     result.Verdict,
+				Stats:       &result.Stats,
+				Report:      result.Report,
+				ImageDigest: result.ImageDigest,
+				Backend:     models.BackendDocker,
+			}
+		}
+		if !r.dockerFailed(ctx, job, err, true) {
+			verdict, message := dockerError(ctx, err)
+			return models.ExecutionResponse{Success: false, Message: message, Verdict: verdict, Backend: models.BackendDocker}
+		}
+	}
+
+	if message := r.localRefusal(job); message != "" {
+		log.Printf("⛔ %s", message)
+		return models.ExecutionResponse{Success: false, Message: message, Verdict: models.VerdictUnsupported}
+	}
+	return r.runLocal(ctx, job)
+}
+
+// StreamResult - итог потокового запуска
+type StreamResult struct {
+	Success     bool
+	Message     string
+	Error       string
+	ExitCode    int
+	Verdict     string
+	Stats       *models.ExecutionStats
+	ImageDigest string
+	Backend     string
+}
+
+// Stream выполняет задание с выводом в streams. hasOutput сообщает, ушел ли
+// вывод клиенту: после этого запасной запуск задублировал бы его
+func (r *Runner) Stream(ctx context.Context, job executor.Job, streams executor.Streams, hasOutput func() bool) StreamResult {
+	if err := job.Validate(); err != nil {
+		return StreamResult{Success: false, Message: err.Error(), ExitCode: 1}
+	}
+
+	if r.useDocker(job) {
+		log.Println("🐳 Attempting Docker streaming execution...")
+		result, err := r.Docker.ExecuteStream(ctx, job, streams)
+		if err == nil {
+			r.breaker.Success()
+			verdict := StreamResult{
+				Success:     result.Success,
+				Message:     "Code executed successfully via Docker",
+				Error:       result.Error,
+				Verdict:     result.Verdict,
+				Stats:       &result.Stats,
+				ImageDigest: result.ImageDigest,
+				Backend:     models.BackendDocker,
+			}
+			if !result.Success {
+				verdict.Message = "Code execution failed in Docker"
+				verdict.ExitCode = 1
+			}
+			return verdict
+		}
+		if !r.dockerFailed(ctx, job, err, !hasOutput()) {
+			verdict, message := dockerError(ctx, err)
+			return StreamResult{Success: false, Message: message, Error: err.Error(), ExitCode: 1, Verdict: verdict, Backend: models.BackendDocker}
+		}
+	}
+
+	if message := r.localRefusal(job); message != "" {
+		log.Printf("⛔ %s", message)
+		return StreamResult{Success: false, Message: message, ExitCode: 1, Verdict: models.VerdictUnsupported}
+	}
+	return r.streamLocal(ctx, job, streams)
+}
+
+// useDocker - идти ли с заданием в Docker: политика позволяет, язык
+// есть в Docker и breaker закрыт
+func (r *Runner) useDocker(job executor.Job) bool {
+	return r.policy.Mode != PolicyLocal && r.dockerSupports(job) && r.breaker.Allow()
+}
+
+// dockerSupports - есть ли у языка задания образ Docker и скачан ли он
+// по последней проверке. Образ юнит-тестов проверка не отмечает: если его
+// нет, запуск вернет ErrImageUnavailable
+func (r *Runner) dockerSupports(job executor.Job) bool {
+	if r.Docker == nil {
+		return false
+	}
+	lang, ok := r.languages.Lookup(job.Language)
+	if !ok {
+		return false
+	}
+	if r.toolchains != nil {
+		if status, ok := r.toolchains.Status(lang.ID); ok && !status.Docker {
+			return false
+		}
+	}
+	lang, ok = job.ResolveLanguage(lang)
+	return ok && lang.Docker.Image != ""
+}
+
+// localAllowed - можно ли запустить задание локально по политике и списку языков
+func (r *Runner) localAllowed(job executor.Job) bool {
+	if r.policy.Mode == PolicyDocker || (r.policy.Mode == PolicyDockerFallback && !r.fallback) {
+		return false
+	}
+	if len(r.local) == 0 {
+		return true
+	}
+	lang, ok := r.languages.Lookup(job.Language)
+	return ok && r.local[lang.ID]
+}
+
+// AllowsLocal - разрешает ли политика запускать язык локально
+// (languages.Executors для проверки тулчейнов)
+func (r *Runner) AllowsLocal(id string) bool {
+	return r.localAllowed(executor.Job{Language: id})
+}
+
+// AllowsDocker - разрешает ли политика запускать язык в Docker
+func (r *Runner) AllowsDocker(id string) bool {
+	return r.policy.Mode != PolicyLocal && r.Docker != nil
+}
+
+// localRefusal - почему задание нельзя запустить локально, пусто - можно
+func (r *Runner) localRefusal(job executor.Job) string {
+	if r.localAllowed(job) {
+		return ""
+	}
+	if r.dockerSupports(job) {
+		return "Docker временно недоступен, попробуйте позже"
+	}
+	return fmt.Sprintf("Язык %s сейчас нельзя запустить: Docker недоступен, а локальный запуск запрещен", job.Language)
+}
+
+// dockerFailed учитывает ошибку Docker. До этой ошибки Docker не вернул
+// результат, значит виноват не код пользователя, а инфраструктура.
+// true - задание можно повторить локально (retryable - повтор безопасен)
+func (r *Runner) dockerFailed(ctx context.Context, job executor.Job, err error, retryable bool) bool {
+	if ctx.Err() != nil {
+		// Клиент ушел или вышло время - Docker тут ни при чем
+		r.breaker.Release()
+		log.Printf("🛑 Docker execution stopped: %v", err)
+		return false
+	}
+
+	if errors.Is(err, services.ErrImageUnavailable) {
+		// Нет образа одного языка - Docker исправен, breaker не трогаем.
+		// Язык считается неподдерживаемым в Docker
+		r.breaker.Release()
+		log.Printf("⚠️ Docker execution skipped: %v", err)
+		return retryable && r.localAllowed(job)
+	}
+
+	log.Printf("❌ Docker execution failed: %v", err)
+	if r.breaker.Failure() {
+		log.Printf("🔌 Docker circuit breaker is open for %s", r.policy.BreakerCooldown)
+	}
+	if r.policy.Mode != PolicyDockerFallback || !retryable || !r.localAllowed(job) {
+		return false
+	}
+	log.Printf("⚠️ Falling back to local execution of %s code", job.Language)
+	return true
+}
+
+// dockerError - вердикт и сообщение для ошибки Docker без запасного запуска
+func dockerError(ctx context.Context, err error) (string, string) {
+	if ctx.Err() != nil {
+		return models.VerdictCancelled, "Execution cancelled"
+	}
+	return "", "Execution failed in Docker: " + err.Error()
+}
+
+// runLocal выполняет задание LocalExecutor и приводит результат к ответу API
+func (r *Runner) runLocal(ctx context.Context, job executor.Job) models.ExecutionResponse {
+	log.Printf("🔧 Executing %s code with local executor", job.Language)
+
+	result, err := r.Local.Execute(ctx, job)
+	if err != nil {
+		log.Printf("❌ Local execution error: %v", err)
+		return models.ExecutionResponse{
+			Success: false,
+			Message: "Execution failed: " + err.Error(),
+			Output:  "",
+			Backend: models.BackendLocal,
+		}
+	}
+
+	// Преобразуем результат из LocalExecutor в models.ExecutionResponse
+	exitCode := result["exitCode"].(int)
+	output := result["output"].(string)
+	errorMsg := result["error"].(string)
+	verdict := result["verdict"].(string)
+	stats := result["stats"].(models.ExecutionStats)
+	report, _ := result["report"].(string)
+
+	success := exitCode == 0
+	finalOutput := output
+	if errorMsg != "" {
+		finalOutput = errorMsg
+		if output != "" {
+			finalOutput = output + "\n" + errorMsg
+		}
+	}
+
+	message := "Код выполнен успешно (локально)"
+	if !success {
+		message = "Ошибка выполнения кода"
+	}
+
+	log.Printf("✅ Local execution completed, success: %t, output length: %d", success, len(finalOutput))
+
+	return models.ExecutionResponse{
+		Success: success,
+		Message: message,
+		Output:  finalOutput,
+		Verdict: verdict,
+		Stats:   &stats,
+		Report:  report,
+		Backend: models.BackendLocal,
+	}
+}
+
+// streamLocal - потоковый запуск LocalExecutor
+func (r *Runner) streamLocal(ctx context.Context, job executor.Job, streams executor.Streams) StreamResult {
+	log.Printf("🔧 Streaming %s code with local executor", job.Language)
+
+	result, err := r.Local.ExecuteStream(ctx, job, streams)
+	if err != nil {
+		log.Printf("❌ Local execution error: %v", err)
+		return StreamResult{
+			Success:  false,
+			Message:  "Execution failed: " + err.Error(),
+			ExitCode: 1,
+			Backend:  models.BackendLocal,
+		}
+	}
+
+	exitCode := result["exitCode"].(int)
+	stats := result["stats"].(models.ExecutionStats)
+	verdict := StreamResult{
+		Success:  exitCode == 0,
+		Message:  "Код выполнен успешно (локально)",
+		Error:    result["error"].(string),
+		ExitCode: exitCode,
+		Verdict:  result["verdict"].(string),
+		Stats:    &stats,
+		Backend:  models.BackendLocal,
+	}
+	if !verdict.Success {
+		verdict.Message = "Ошибка выполнения кода"
+	}
+	return verdict
+}
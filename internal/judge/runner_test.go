@@ -0,0 +1,84 @@
+package judge
+
+import (
+	"backend/internal/executor"
+	"backend/internal/languages"
+	"backend/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunnerDockerFailed(t *testing.T) {
+	imageMissing := fmt.Errorf("%w: trenager/judge-ruby:3.3", services.ErrImageUnavailable)
+	tests := []struct {
+		name         string
+		mode         string
+		fallback     bool
+		err          error
+		wantFallback bool
+		wantState    string
+	}{
+		{name: "docker failure opens the breaker", mode: PolicyDocker, err: errors.New("daemon is down"), wantState: BreakerOpen},
+		{name: "docker failure falls back in the sandbox", mode: PolicyDockerFallback, fallback: true, err: errors.New("daemon is down"), wantFallback: true, wantState: BreakerOpen},
+		{name: "missing image keeps the breaker closed", mode: PolicyDocker, err: imageMissing, wantState: BreakerClosed},
+		{name: "missing image falls back in the sandbox", mode: PolicyDockerFallback, fallback: true, err: imageMissing, wantFallback: true, wantState: BreakerClosed},
+		{name: "missing image without the sandbox is refused", mode: PolicyDockerFallback, err: imageMissing, wantState: BreakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &Runner{
+				policy:   Policy{Mode: tt.mode},
+				local:    map[string]bool{},
+				fallback: tt.fallback,
+				breaker:  NewBreaker(1, time.Minute),
+			}
+			if !runner.breaker.Allow() {
+				t.Fatal("new breaker does not allow Docker")
+			}
+			got := runner.dockerFailed(context.Background(), executor.Job{Language: "ruby"}, tt.err, true)
+			if got != tt.wantFallback {
+				t.Errorf("fallback = %t, want %t", got, tt.wantFallback)
+			}
+			if state := runner.breaker.Status().State; state != tt.wantState {
+				t.Errorf("breaker = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+// toolchainStatuses - результаты проверки языков для теста
+type toolchainStatuses map[string]languages.Status
+
+func (s toolchainStatuses) Status(id string) (languages.Status, bool) {
+	status, ok := s[id]
+	return status, ok
+}
+
+func TestRunnerDockerSupports(t *testing.T) {
+	registry, err := languages.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		language   string
+		toolchains Toolchains
+		want       bool
+	}{
+		{name: "no probe yet", language: "rb", want: true},
+		{name: "image is pulled", language: "rb", toolchains: toolchainStatuses{"ruby": {Docker: true}}, want: true},
+		{name: "image is missing", language: "rb", toolchains: toolchainStatuses{"ruby": {Docker: false}}},
+		{name: "unknown language", language: "cobol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &Runner{Docker: &services.DockerService{}, languages: registry, toolchains: tt.toolchains}
+			if got := runner.dockerSupports(executor.Job{Language: tt.language}); got != tt.want {
+				t.Errorf("dockerSupports = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package judge
+
+import (
+	"backend/internal/models"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Transport - как воркер разговаривает с очередью: напрямую в том же
+// процессе (LocalTransport) или по HTTP с API (HTTPTransport)
+type Transport interface {
+	Register(ctx context.Context, info WorkerInfo) (string, error)
+	// Heartbeat возвращает ID заданий, которые надо остановить
+	Heartbeat(ctx context.Context, workerID string) ([]string, error)
+	// Lease возвращает nil, если заданий нет
+	Lease(ctx context.Context, workerID string) (*Assignment, error)
+	Complete(ctx context.Context, workerID, jobID string, result models.ExecutionResponse) error
+}
+
+// LocalTransport - очередь в том же процессе (встроенные воркеры и тесты)
+type LocalTransport struct {
+	Queue *Queue
+}
+
+func (t LocalTransport) Register(ctx context.Context, info WorkerInfo) (string, error) {
+	return t.Queue.Register(info), nil
+}
+
+func (t LocalTransport) Heartbeat(ctx context.Context, workerID string) ([]string, error) {
+	return t.Queue.Heartbeat(workerID)
+}
+
+func (t LocalTransport) Lease(ctx context.Context, workerID string) (*Assignment, error) {
+	return t.Queue.Lease(ctx, workerID)
+}
+
+func (t LocalTransport) Complete(ctx context.Context, workerID, jobID string, result models.ExecutionResponse) error {
+	return t.Queue.Complete(workerID, jobID, result)
+}
+
+// Протокол HTTP: POST /api/judge/{register,heartbeat,lease,complete} с JSON
+// и заголовком Authorization: Bearer <JUDGE_TOKEN>. Lease без задания
+// отвечает 204, неизвестный воркер - 404, устаревшая аренда - 409
+
+type registerResponse struct {
+	WorkerID string `json:"worker_id"`
+}
+
+type workerRequest struct {
+	WorkerID string `json:"worker_id"`
+}
+
+type heartbeatResponse struct {
+	Cancel []string `json:"cancel"`
+}
+
+type completeRequest struct {
+	WorkerID string                   `json:"worker_id"`
+	JobID    string                   `json:"job_id"`
+	Result   models.ExecutionResponse `json:"result"`
+	// Report не сериализуется в ExecutionResponse, передаем отдельно
+	Report string `json:"report,omitempty"`
+}
+
+// HTTPTransport - клиент очереди в API по адресу BaseURL
+type HTTPTransport struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// NewHTTPTransport создает клиент. Таймаут запросов больше LeaseWait,
+// чтобы долгий опрос Lease не обрывался
+func NewHTTPTransport(baseURL, token string, leaseWait time.Duration) *HTTPTransport {
+	return &HTTPTransport{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: leaseWait + 30*time.Second},
+	}
+}
+
+func (t *HTTPTransport) Register(ctx context.Context, info WorkerInfo) (string, error) {
+	var resp registerResponse
+	if _, err := t.post(ctx, "register", info, &resp); err != nil {
+		return "", err
+	}
+	return resp.WorkerID, nil
+}
+
+func (t *HTTPTransport) Heartbeat(ctx context.Context, workerID string) ([]string, error) {
+	var resp heartbeatResponse
+	if _, err := t.post(ctx, "heartbeat", workerRequest{workerID}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Cancel, nil
+}
+
+func (t *HTTPTransport) Lease(ctx context.Context, workerID string) (*Assignment, error) {
+	var assignment Assignment
+	status, err := t.post(ctx, "lease", workerRequest{workerID}, &assignment)
+	if err != nil || status == http.StatusNoContent {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (t *HTTPTransport) Complete(ctx context.Context, workerID, jobID string, result models.ExecutionResponse) error {
+	_, err := t.post(ctx, "complete", completeRequest{WorkerID: workerID, JobID: jobID, Result: result, Report: result.Report}, nil)
+	return err
+}
+
+// post отправляет запрос и разбирает ответ в out. Коды ошибок
+// превращаются обратно в ErrUnknownWorker и ErrStaleLease
+func (t *HTTPTransport) post(ctx context.Context, method string, body, out interface{}) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.BaseURL+"/api/judge/"+method, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+t.Token)
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if out == nil {
+			return resp.StatusCode, nil
+		}
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusNoContent:
+		return resp.StatusCode, nil
+	case http.StatusNotFound:
+		return resp.StatusCode, ErrUnknownWorker
+	case http.StatusConflict:
+		return resp.StatusCode, ErrStaleLease
+	default:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("judge %s: %s: %s", method, resp.Status, strings.TrimSpace(string(message)))
+	}
+}
+
+// Handler - сторона API для HTTPTransport. Все методы требуют token
+func Handler(queue *Queue, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		method := strings.TrimPrefix(r.URL.Path, "/api/judge/")
+		if method == "status" && r.Method == http.MethodGet {
+			writeJSON(w, queue.Status())
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch method {
+		case "register":
+			var info WorkerInfo
+			if !decode(w, r, &info) {
+				return
+			}
+			writeJSON(w, registerResponse{WorkerID: queue.Register(info)})
+		case "heartbeat":
+			var req workerRequest
+			if !decode(w, r, &req) {
+				return
+			}
+			cancel, err := queue.Heartbeat(req.WorkerID)
+			if writeError(w, err) {
+				return
+			}
+			writeJSON(w, heartbeatResponse{Cancel: cancel})
+		case "lease":
+			var req workerRequest
+			if !decode(w, r, &req) {
+				return
+			}
+			assignment, err := queue.Lease(r.Context(), req.WorkerID)
+			if r.Context().Err() != nil {
+				// Воркер отключился, пока ждал: задание ему уже не доставить
+				if assignment != nil {
+					queue.Release(req.WorkerID, assignment.ID)
+				}
+				return
+			}
+			if writeError(w, err) {
+				return
+			}
+			if assignment == nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			writeJSON(w, assignment)
+		case "complete":
+			var req completeRequest
+			if !decode(w, r, &req) {
+				return
+			}
+			req.Result.Report = req.Report
+			if writeError(w, queue.Complete(req.WorkerID, req.JobID, req.Result)) {
+				return
+			}
+			writeJSON(w, map[string]bool{"ok": true})
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeError отвечает кодом для ошибки очереди. false - ошибки нет
+func writeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrUnknownWorker):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrStaleLease):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("❌ Judge queue: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
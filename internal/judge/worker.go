@@ -0,0 +1,222 @@
+package judge
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Worker берет задания из очереди через Transport и выполняет их
+// функцией run. Slots заданий выполняются одновременно, heartbeat
+// идет отдельно и останавливает задания, которые больше не нужны
+
+// RunFunc выполняет задание (обычно Runner.Run)
+type RunFunc func(ctx context.Context, job executor.Job) models.ExecutionResponse
+
+// Worker - воркер проверки
+type Worker struct {
+	transport Transport
+	info      WorkerInfo
+	run       RunFunc
+	heartbeat time.Duration
+
+	mu      sync.Mutex
+	id      string
+	running map[string]context.CancelFunc
+}
+
+// NewWorker создает воркера. heartbeat - период heartbeat, он должен быть
+// заметно меньше WorkerTimeout очереди
+func NewWorker(transport Transport, info WorkerInfo, heartbeat time.Duration, run RunFunc) *Worker {
+	if info.Slots <= 0 {
+		info.Slots = 1
+	}
+	return &Worker{
+		transport: transport,
+		info:      info,
+		run:       run,
+		heartbeat: heartbeat,
+		running:   make(map[string]context.CancelFunc),
+	}
+}
+
+// retryDelay - пауза перед повтором, если API недоступен
+const retryDelay = 2 * time.Second
+
+// Run регистрируется и выполняет задания, пока не отменен ctx.
+// Начатые задания при отмене ctx останавливаются
+func (w *Worker) Run(ctx context.Context) {
+	if _, err := w.register(ctx, ""); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.heartbeats(ctx)
+	}()
+	for i := 0; i < w.info.Slots; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.slot(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// register регистрирует воркера заново, если его текущий ID все еще stale
+// (очередь забыла воркера, например после перезапуска API). Повторяет
+// попытки, пока не получится или не отменят ctx
+func (w *Worker) register(ctx context.Context, stale string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.id != stale {
+		// Другой слот уже перерегистрировался
+		return w.id, nil
+	}
+
+	for {
+		id, err := w.transport.Register(ctx, w.info)
+		if err == nil {
+			w.id = id
+			log.Printf("👷 Registered as judge worker %s", id[:8])
+			return id, nil
+		}
+		log.Printf("⚠️ Failed to register judge worker: %v", err)
+		if !sleep(ctx, retryDelay) {
+			return "", ctx.Err()
+		}
+	}
+}
+
+func (w *Worker) workerID() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.id
+}
+
+// heartbeats шлет heartbeat и останавливает снятые задания
+func (w *Worker) heartbeats(ctx context.Context) {
+	ticker := time.NewTicker(w.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		id := w.workerID()
+		cancel, err := w.transport.Heartbeat(ctx, id)
+		if errors.Is(err, ErrUnknownWorker) {
+			// Задания старого ID очередь уже раздала другим
+			w.cancelAll()
+			w.register(ctx, id)
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️ Judge heartbeat failed: %v", err)
+			continue
+		}
+		for _, jobID := range cancel {
+			w.cancelJob(jobID)
+		}
+	}
+}
+
+// slot берет и выполняет задания по одному
+func (w *Worker) slot(ctx context.Context) {
+	for ctx.Err() == nil {
+		id := w.workerID()
+		assignment, err := w.transport.Lease(ctx, id)
+		if errors.Is(err, ErrUnknownWorker) {
+			w.register(ctx, id)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("⚠️ Judge lease failed: %v", err)
+				sleep(ctx, retryDelay)
+			}
+			continue
+		}
+		if assignment != nil {
+			w.execute(ctx, id, assignment)
+		}
+	}
+}
+
+// execute выполняет задание до конца аренды и отправляет результат
+func (w *Worker) execute(ctx context.Context, workerID string, assignment *Assignment) {
+	// Конец аренды только снимает брошенное задание. Дедлайном ctx его не
+	// делаем: с дедлайном исполнитель не ставит лимит времени задачи
+	// (WithDefaultTimeout), и вечный цикл вместо TLE ждал бы конца аренды
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	expire := time.AfterFunc(time.Until(assignment.Deadline), cancel)
+	defer expire.Stop()
+	w.mu.Lock()
+	w.running[assignment.ID] = cancel
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.running, assignment.ID)
+		w.mu.Unlock()
+	}()
+
+	log.Printf("📥 Job %s: %s", assignment.ID[:8], assignment.Job.Language)
+	result := w.run(jobCtx, assignment.Job)
+	if jobCtx.Err() != nil {
+		// Задание сняли или аренда истекла - результат уже никому не нужен
+		log.Printf("🛑 Job %s stopped: %v", assignment.ID[:8], jobCtx.Err())
+		return
+	}
+
+	// Результат важнее паузы: повторяем, пока API не примет его или не откажет
+	for attempt := 0; attempt < 3; attempt++ {
+		err := w.transport.Complete(ctx, workerID, assignment.ID, result)
+		if err == nil || errors.Is(err, ErrStaleLease) || errors.Is(err, ErrUnknownWorker) {
+			if err != nil {
+				log.Printf("⚠️ Result of job %s was not accepted: %v", assignment.ID[:8], err)
+			}
+			return
+		}
+		log.Printf("⚠️ Failed to send result of job %s: %v", assignment.ID[:8], err)
+		if !sleep(ctx, retryDelay) {
+			return
+		}
+	}
+}
+
+func (w *Worker) cancelJob(jobID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if cancel, ok := w.running[jobID]; ok {
+		log.Printf("🛑 Job %s cancelled by the API", jobID[:8])
+		cancel()
+	}
+}
+
+func (w *Worker) cancelAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, cancel := range w.running {
+		cancel()
+	}
+}
+
+// sleep ждет d или отмены ctx. false - ctx отменен
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,84 @@
+package judge
+
+import (
+	"backend/internal/executor"
+	"backend/internal/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport запоминает отправленные результаты
+type recordingTransport struct {
+	mu        sync.Mutex
+	completed []string
+}
+
+func (t *recordingTransport) Register(ctx context.Context, info WorkerInfo) (string, error) {
+	return "worker", nil
+}
+
+func (t *recordingTransport) Heartbeat(ctx context.Context, workerID string) ([]string, error) {
+	return nil, nil
+}
+
+func (t *recordingTransport) Lease(ctx context.Context, workerID string) (*Assignment, error) {
+	return nil, nil
+}
+
+func (t *recordingTransport) Complete(ctx context.Context, workerID, jobID string, result models.ExecutionResponse) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.completed = append(t.completed, jobID)
+	return nil
+}
+
+func TestWorkerExecuteLease(t *testing.T) {
+	tests := []struct {
+		name         string
+		lease        time.Duration
+		block        bool // задание выполняется, пока его не остановят
+		wantComplete bool
+	}{
+		{name: "result is sent", lease: time.Minute, wantComplete: true},
+		{name: "expired lease stops the job", lease: 100 * time.Millisecond, block: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			run := func(ctx context.Context, job executor.Job) models.ExecutionResponse {
+				// Дедлайн ctx отключил бы лимит времени задачи в исполнителе
+				_, hasDeadline = ctx.Deadline()
+				if tt.block {
+					<-ctx.Done()
+				}
+				return models.ExecutionResponse{Success: true}
+			}
+			transport := &recordingTransport{}
+			worker := NewWorker(transport, WorkerInfo{Name: "w"}, time.Hour, run)
+
+			done := make(chan struct{})
+			go func() {
+				worker.execute(context.Background(), "worker", &Assignment{
+					ID:       "job-0000000001",
+					Job:      executor.Job{Language: "python"},
+					Deadline: time.Now().Add(tt.lease),
+				})
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("job was not stopped at the end of the lease")
+			}
+
+			if hasDeadline {
+				t.Error("job context has a deadline, the task time limit would be skipped")
+			}
+			if got := len(transport.completed) == 1; got != tt.wantComplete {
+				t.Errorf("result sent = %t, want %t", got, tt.wantComplete)
+			}
+		})
+	}
+}
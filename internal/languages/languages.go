@@ -0,0 +1,340 @@
+package languages
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Реестр языков программирования. Все, что нужно знать исполнителям
+// о языке (файлы, команды сборки и запуска, образ Docker, лимиты),
+// описано в одном JSON файле. По умолчанию используется встроенный
+// languages.json, свой файл подключается через LANGUAGES_FILE
+// (например, чтобы на Windows запускать python вместо python3).
+//
+// В командах и переменных окружения доступны подстановки:
+//   {dir}   - рабочая директория запуска (/box/app в Docker)
+//   {file}  - путь к файлу с кодом
+//   {sources} - все исходники решения с расширением основного файла
+//               (отдельными аргументами, только как аргумент целиком)
+//   {out}   - путь к собранной программе
+//   {cache} - общий кэш сборки локального исполнителя
+
+//go:embed languages.json
+var defaultConfig []byte
+
+// Значения по умолчанию для лимитов
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultCompileTimeout = 60 * time.Second
+)
+
+// Language описание одного языка
+type Language struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	FileName string   `json:"file_name"`
+	Template string   `json:"template,omitempty"` // код по умолчанию в редакторе
+
+	// ExtraFiles - файлы, которые кладутся рядом с кодом (например, go.mod)
+	ExtraFiles map[string]string `json:"extra_files,omitempty"`
+
+	Compile    []string `json:"compile,omitempty"` // пусто - язык интерпретируемый
+	CompileEnv []string `json:"compile_env,omitempty"`
+	Run        []string `json:"run"`
+	RunEnv     []string `json:"run_env,omitempty"`
+	// VersionCmd печатает версию тулчейна (первая непустая строка вывода)
+	VersionCmd []string `json:"version_cmd,omitempty"`
+
+	// CompileOutsideSandbox - собирать вне песочницы локального исполнителя.
+	// Только для компиляторов, которые не исполняют код решения (Go без cgo):
+	// так запуски могут делить общий кэш сборки
+	CompileOutsideSandbox bool `json:"compile_outside_sandbox,omitempty"`
+	// UnlimitedAddressSpace - не ставить RLIMIT_AS. JVM, V8 и рантайм Go
+	// резервируют много виртуальной памяти, их ограничивает только cgroup
+	UnlimitedAddressSpace bool `json:"unlimited_address_space,omitempty"`
+
+	Docker DockerConfig `json:"docker"`
+	Limits Limits       `json:"limits"`
+
+	// Unit - запуск юнит-тестов учителя вместо программы (nil - не поддерживается)
+	Unit *UnitConfig `json:"unit,omitempty"`
+}
+
+// UnitConfig запуск решения под тестовым фреймворком языка.
+// Код ученика пишется в FileName, тесты учителя - в TestFile.
+// Фреймворк пишет отчет в Report (пусто - в stdout) в формате Format
+type UnitConfig struct {
+	Framework  string            `json:"framework"`
+	FileName   string            `json:"file_name"`
+	TestFile   string            `json:"test_file"`
+	ExtraFiles map[string]string `json:"extra_files,omitempty"` // заменяют файлы языка
+	Compile    []string          `json:"compile,omitempty"`
+	Run        []string          `json:"run"`
+	Report     string            `json:"report,omitempty"`
+	Format     string            `json:"format"` // junit, jest, gotest
+	// DockerImage - образ с фреймворком (пусто - юнит-тесты только локально)
+	DockerImage string `json:"docker_image,omitempty"`
+}
+
+// DockerConfig запуск языка в контейнере. Пустые команды берутся из Language
+type DockerConfig struct {
+	Image   string   `json:"image"`
+	Compile []string `json:"compile,omitempty"`
+	Run     []string `json:"run,omitempty"`
+}
+
+// Limits лимиты языка. Нулевое значение - значение по умолчанию.
+// Множители применяются к лимитам задачи: JVM стартует дольше и ест больше памяти
+type Limits struct {
+	TimeoutSeconds        int     `json:"timeout_seconds,omitempty"`
+	CompileTimeoutSeconds int     `json:"compile_timeout_seconds,omitempty"`
+	MemoryMB              int     `json:"memory_mb,omitempty"`
+	TimeMultiplier        float64 `json:"time_multiplier,omitempty"`
+	MemoryMultiplier      float64 `json:"memory_multiplier,omitempty"`
+}
+
+// Timeout - сколько может работать программа
+func (l *Language) Timeout() time.Duration {
+	if l.Limits.TimeoutSeconds > 0 {
+		return time.Duration(l.Limits.TimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// CompileTimeout - сколько может работать компилятор
+func (l *Language) CompileTimeout() time.Duration {
+	if l.Limits.CompileTimeoutSeconds > 0 {
+		return time.Duration(l.Limits.CompileTimeoutSeconds) * time.Second
+	}
+	return defaultCompileTimeout
+}
+
+// ScaleTime применяет множитель времени языка
+func (l *Language) ScaleTime(d time.Duration) time.Duration {
+	if l.Limits.TimeMultiplier > 0 {
+		return time.Duration(float64(d) * l.Limits.TimeMultiplier)
+	}
+	return d
+}
+
+// ScaleMemory применяет множитель памяти языка
+func (l *Language) ScaleMemory(mb int) int {
+	if l.Limits.MemoryMultiplier > 0 {
+		return int(float64(mb) * l.Limits.MemoryMultiplier)
+	}
+	return mb
+}
+
+// Compiled - нужна ли сборка перед запуском
+func (l *Language) Compiled() bool {
+	return len(l.Compile) > 0
+}
+
+// DockerCompile - команда сборки в контейнере
+func (l *Language) DockerCompile() []string {
+	if len(l.Docker.Compile) > 0 {
+		return l.Docker.Compile
+	}
+	return l.Compile
+}
+
+// DockerRun - команда запуска в контейнере
+func (l *Language) DockerRun() []string {
+	if len(l.Docker.Run) > 0 {
+		return l.Docker.Run
+	}
+	return l.Run
+}
+
+// UnitVariant - язык, который вместо программы запускает юнит-тесты
+func (l *Language) UnitVariant() (*Language, bool) {
+	if l.Unit == nil {
+		return nil, false
+	}
+	variant := *l
+	variant.FileName = l.Unit.FileName
+	variant.Compile = l.Unit.Compile
+	variant.Run = l.Unit.Run
+	// Без своего образа юнит-тесты в Docker не запускаются
+	variant.Docker = DockerConfig{Image: l.Unit.DockerImage}
+	if len(l.Unit.ExtraFiles) > 0 {
+		variant.ExtraFiles = make(map[string]string)
+		for name, content := range l.ExtraFiles {
+			variant.ExtraFiles[name] = content
+		}
+		for name, content := range l.Unit.ExtraFiles {
+			variant.ExtraFiles[name] = content
+		}
+	}
+	return &variant, true
+}
+
+// Tools - программы, которые нужны для локальной сборки и запуска.
+// Команды с подстановкой ({out}) - собранная программа, их не ищем
+func (l *Language) Tools() []string {
+	var tools []string
+	for _, cmd := range [][]string{l.Compile, l.Run} {
+		if len(cmd) == 0 || strings.HasPrefix(cmd[0], "{") {
+			continue
+		}
+		if len(tools) == 0 || tools[len(tools)-1] != cmd[0] {
+			tools = append(tools, cmd[0])
+		}
+	}
+	return tools
+}
+
+// Vars - значения подстановок для одного запуска
+type Vars struct {
+	Dir     string
+	File    string
+	Out     string
+	Cache   string
+	Sources []string
+}
+
+// VarsFor - подстановки для запуска языка в dir
+func (l *Language) VarsFor(dir, cache string) Vars {
+	return Vars{
+		Dir:   dir,
+		File:  filepath.ToSlash(filepath.Join(dir, l.FileName)),
+		Out:   filepath.ToSlash(filepath.Join(dir, "main")),
+		Cache: cache,
+	}
+}
+
+// WithSources задает исходники решения, paths - относительно Dir
+func (v Vars) WithSources(paths []string) Vars {
+	v.Sources = make([]string, len(paths))
+	for i, p := range paths {
+		v.Sources[i] = v.Dir + "/" + p
+	}
+	return v
+}
+
+// Expand подставляет значения в команду или список переменных окружения
+func (v Vars) Expand(args []string) []string {
+	replacer := strings.NewReplacer(
+		"{dir}", v.Dir,
+		"{file}", v.File,
+		"{out}", v.Out,
+		"{cache}", v.Cache,
+	)
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "{sources}" {
+			if len(v.Sources) == 0 {
+				expanded = append(expanded, v.File)
+			} else {
+				expanded = append(expanded, v.Sources...)
+			}
+			continue
+		}
+		expanded = append(expanded, replacer.Replace(arg))
+	}
+	return expanded
+}
+
+// Registry - набор языков с поиском по id и алиасам
+type Registry struct {
+	languages []*Language
+	byName    map[string]*Language
+}
+
+// Load читает реестр из файла path, а при пустом path - встроенный
+func Load(path string) (*Registry, error) {
+	data := defaultConfig
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read languages file: %w", err)
+		}
+	}
+	return Parse(data)
+}
+
+// Parse разбирает и проверяет JSON со списком языков
+func Parse(data []byte) (*Registry, error) {
+	var file struct {
+		Languages []*Language `json:"languages"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid languages config: %w", err)
+	}
+
+	registry := &Registry{byName: make(map[string]*Language)}
+	for _, lang := range file.Languages {
+		if lang.ID == "" || lang.FileName == "" || len(lang.Run) == 0 {
+			return nil, fmt.Errorf("language %q: id, file_name and run are required", lang.ID)
+		}
+		if lang.Name == "" {
+			lang.Name = lang.ID
+		}
+		if unit := lang.Unit; unit != nil && (unit.FileName == "" || unit.TestFile == "" || len(unit.Run) == 0 || unit.Format == "") {
+			return nil, fmt.Errorf("language %q: unit needs file_name, test_file, run and format", lang.ID)
+		}
+		for _, name := range append([]string{lang.ID}, lang.Aliases...) {
+			key := strings.ToLower(name)
+			if _, exists := registry.byName[key]; exists {
+				return nil, fmt.Errorf("language name %q is used twice", name)
+			}
+			registry.byName[key] = lang
+		}
+		registry.languages = append(registry.languages, lang)
+	}
+
+	if len(registry.languages) == 0 {
+		return nil, fmt.Errorf("no languages configured")
+	}
+	return registry, nil
+}
+
+// Lookup ищет язык по id или алиасу без учета регистра
+func (r *Registry) Lookup(name string) (*Language, bool) {
+	lang, ok := r.byName[strings.ToLower(strings.TrimSpace(name))]
+	return lang, ok
+}
+
+// All - все языки в порядке из файла
+func (r *Registry) All() []*Language {
+	return r.languages
+}
+
+// IDs - идентификаторы всех языков
+func (r *Registry) IDs() []string {
+	ids := make([]string, len(r.languages))
+	for i, lang := range r.languages {
+		ids[i] = lang.ID
+	}
+	return ids
+}
+
+// Images - образы Docker всех языков и их юнит-тестов без повторов
+func (r *Registry) Images() []string {
+	var images []string
+	seen := make(map[string]bool)
+	for _, lang := range r.languages {
+		for _, image := range []string{lang.Docker.Image, lang.unitImage()} {
+			if image != "" && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+func (l *Language) unitImage() string {
+	if l.Unit == nil {
+		return ""
+	}
+	return l.Unit.DockerImage
+}
@@ -0,0 +1,200 @@
+package languages
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Проверка тулчейнов: какие языки реально можно запустить на этом сервере.
+// Локально ищем программы из команд сборки и запуска и спрашиваем версию,
+// для Docker проверяем, что образ уже скачан
+
+// probeTimeout - сколько ждем команду версии (kotlinc и javac стартуют долго)
+const probeTimeout = 10 * time.Second
+
+// ImageChecker проверяет наличие образа Docker
+type ImageChecker interface {
+	ImageExists(ctx context.Context, image string) (bool, error)
+}
+
+// Executors - политика исполнителя: каким способом разрешено запускать язык.
+// Тулчейн, который политика не разрешает, не делает язык доступным
+type Executors interface {
+	AllowsLocal(id string) bool
+	AllowsDocker(id string) bool
+}
+
+// Status доступность языка
+type Status struct {
+	ID           string    `json:"id"`
+	Available    bool      `json:"available"`
+	Local        bool      `json:"local"`
+	LocalVersion string    `json:"local_version,omitempty"`
+	Missing      []string  `json:"missing,omitempty"` // чего не хватает для локального запуска
+	Unit         bool      `json:"unit"`              // можно запускать юнит-тесты
+	Docker       bool      `json:"docker"`
+	DockerImage  string    `json:"docker_image,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Prober хранит последний результат проверки каждого языка
+type Prober struct {
+	registry *Registry
+	images   ImageChecker // nil - Docker недоступен
+	policy   Executors    // nil - разрешено все
+
+	mu       sync.RWMutex
+	statuses map[string]Status
+}
+
+// NewProber создает проверку для языков registry. images и policy могут быть nil
+func NewProber(registry *Registry, images ImageChecker, policy Executors) *Prober {
+	return &Prober{
+		registry: registry,
+		images:   images,
+		policy:   policy,
+		statuses: make(map[string]Status),
+	}
+}
+
+// Probe проверяет все языки параллельно
+func (p *Prober) Probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, lang := range p.registry.All() {
+		wg.Add(1)
+		go func(lang *Language) {
+			defer wg.Done()
+			status := p.probeLanguage(ctx, lang)
+
+			p.mu.Lock()
+			previous, known := p.statuses[lang.ID]
+			p.statuses[lang.ID] = status
+			p.mu.Unlock()
+
+			if !known || previous.Available != status.Available {
+				if status.Available {
+					log.Printf("🧰 %s available (local=%t %s, docker=%t)", lang.ID, status.Local, status.LocalVersion, status.Docker)
+				} else if status.Local || status.Docker {
+					log.Printf("⚠️ %s unavailable: not allowed by the executor policy (local=%t, docker=%t)", lang.ID, status.Local, status.Docker)
+				} else {
+					log.Printf("⚠️ %s unavailable: missing %s", lang.ID, strings.Join(status.Missing, ", "))
+				}
+			}
+		}(lang)
+	}
+	wg.Wait()
+}
+
+// Run перепроверяет языки каждые interval, пока ctx не отменен
+func (p *Prober) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Probe(ctx)
+		}
+	}
+}
+
+// Status - последний результат проверки языка по id
+func (p *Prober) Status(id string) (Status, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status, ok := p.statuses[id]
+	return status, ok
+}
+
+// Statuses - результаты по всем языкам в порядке реестра
+func (p *Prober) Statuses() []Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(p.statuses))
+	for _, lang := range p.registry.All() {
+		if status, ok := p.statuses[lang.ID]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func (p *Prober) probeLanguage(ctx context.Context, lang *Language) Status {
+	status := Status{
+		ID:          lang.ID,
+		DockerImage: lang.Docker.Image,
+		CheckedAt:   time.Now(),
+	}
+
+	status.Missing = missingTools(lang)
+	status.Local = len(status.Missing) == 0
+	if status.Local && len(lang.VersionCmd) > 0 {
+		status.LocalVersion = ProbeVersion(ctx, lang.VersionCmd)
+	}
+
+	if p.images != nil && lang.Docker.Image != "" {
+		exists, err := p.images.ImageExists(ctx, lang.Docker.Image)
+		if err != nil {
+			log.Printf("⚠️ Failed to check image %s: %v", lang.Docker.Image, err)
+		}
+		status.Docker = exists
+	}
+
+	// Local и Docker - что установлено, Available и Unit - что из этого
+	// разрешает запускать политика
+	local := status.Local && p.allowsLocal(lang.ID)
+	docker := status.Docker && p.allowsDocker(lang.ID)
+	if variant, ok := lang.UnitVariant(); ok {
+		status.Unit = (len(missingTools(variant)) == 0 && p.allowsLocal(lang.ID)) || (docker && variant.Docker.Image != "")
+	}
+
+	status.Available = local || docker
+	return status
+}
+
+func (p *Prober) allowsLocal(id string) bool {
+	return p.policy == nil || p.policy.AllowsLocal(id)
+}
+
+func (p *Prober) allowsDocker(id string) bool {
+	return p.policy == nil || p.policy.AllowsDocker(id)
+}
+
+// missingTools - программы языка, которых нет в PATH
+func missingTools(lang *Language) []string {
+	var missing []string
+	for _, tool := range lang.Tools() {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// ProbeVersion возвращает первую непустую строку вывода команды версии.
+// javac и kotlinc печатают версию в stderr, поэтому читаем оба потока
+func ProbeVersion(ctx context.Context, command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	if err != nil && len(output) == 0 {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
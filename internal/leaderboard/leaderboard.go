@@ -0,0 +1,371 @@
+package leaderboard
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Таблицы лидеров по прогрессу учеников: общая, по треку (теме задач) и
+// по группе. Место определяют решенные задачи, затем сумма лучших баллов,
+// затем время последнего улучшения - кто раньше, тот выше.
+// Таблицы живут в памяти уже отсортированными: при старте собираются из
+// user_progress, а каждая проверка передвигает в них только одного ученика
+
+// Scope - какая таблица: общая, по треку или по группе
+type Scope struct {
+	Track string
+	Group string
+}
+
+func (s Scope) key() string {
+	switch {
+	case s.Group != "":
+		return "group:" + s.Group
+	case s.Track != "":
+		return "track:" + s.Track
+	}
+	return "global"
+}
+
+// Settings - как ученик участвует в таблицах
+type Settings struct {
+	UserID string `json:"-"`
+	// Username - имя пользователя на момент последней проверки: пользователи
+	// живут в памяти, и после перезапуска имя берется из настроек
+	Username string `json:"-"`
+	Name     string `json:"name,omitempty"`  // имя в таблице, пусто - имя пользователя
+	Group    string `json:"group,omitempty"` // код группы (класса, курса)
+	Hidden   bool   `json:"hidden"`          // не показывать в таблицах
+}
+
+// MaxNameLength - наибольшая длина имени и кода группы
+const MaxNameLength = 50
+
+// ErrInvalidSettings - имя или группа слишком длинные
+var ErrInvalidSettings = errors.New("name and group must be at most 50 characters")
+
+// SettingsStore - хранилище настроек участия
+type SettingsStore interface {
+	List(ctx context.Context) ([]Settings, error)
+	// Save сохраняет настройки, Username не меняет
+	Save(ctx context.Context, settings Settings) error
+	SaveUsername(ctx context.Context, userID, username string) error
+}
+
+// Row - строка таблицы
+type Row struct {
+	Rank       int        `json:"rank"`
+	Name       string     `json:"name"`
+	Solved     int        `json:"solved"`
+	Score      float64    `json:"score"`
+	ImprovedAt *time.Time `json:"improved_at,omitempty"`
+	Me         bool       `json:"me,omitempty"`
+}
+
+// Page - часть таблицы
+type Page struct {
+	Scope  string `json:"scope"`
+	Total  int    `json:"total"`
+	Offset int    `json:"offset"`
+	Rows   []Row  `json:"rows"`
+	// Me - место запросившего, nil - его нет в таблице
+	Me *Row `json:"me,omitempty"`
+}
+
+type taskResult struct {
+	completed  bool
+	best       float64
+	improvedAt time.Time
+}
+
+type participant struct {
+	id       string
+	username string
+	settings Settings
+	tasks    map[string]*taskResult
+	boards   []string // ключи таблиц, где ученик сейчас стоит
+}
+
+// standing - место ученика в одной таблице
+type standing struct {
+	user       *participant
+	solved     int
+	score      float64
+	improvedAt time.Time
+}
+
+// Leaderboard - все таблицы лидеров
+type Leaderboard struct {
+	topics   map[string]string // задача -> трек
+	settings SettingsStore
+
+	mu     sync.Mutex
+	users  map[string]*participant
+	boards map[string][]*standing // отсортированы по месту
+}
+
+// New создает пустые таблицы. topics - трек каждой задачи
+func New(topics map[string]string, settings SettingsStore) *Leaderboard {
+	return &Leaderboard{
+		topics:   topics,
+		settings: settings,
+		users:    make(map[string]*participant),
+		boards:   make(map[string][]*standing),
+	}
+}
+
+// Load собирает таблицы из прогресса и настроек всех учеников
+func (l *Leaderboard) Load(records []models.UserProgress, settings []Settings) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, s := range settings {
+		u := l.user(s.UserID)
+		u.settings = s
+		u.username = s.Username
+	}
+	for _, record := range records {
+		if _, ok := l.topics[record.TaskID]; !ok {
+			continue
+		}
+		l.user(record.UserID).tasks[record.TaskID] = &taskResult{
+			completed:  record.Completed,
+			best:       record.BestScore,
+			improvedAt: record.ImprovedAt,
+		}
+	}
+	for _, u := range l.users {
+		l.place(u)
+	}
+}
+
+// Record учитывает проверку так же, как progress.Store.Record, и
+// запоминает имя пользователя, если оно новое
+func (l *Leaderboard) Record(ctx context.Context, user *models.User, taskID string, passed bool, score float64, at time.Time) error {
+	if _, ok := l.topics[taskID]; !ok {
+		return nil
+	}
+	l.mu.Lock()
+	u := l.user(user.ID)
+	renamed := u.username != user.Username
+	u.username = user.Username
+	result, ok := u.tasks[taskID]
+	if !ok {
+		result = &taskResult{}
+		u.tasks[taskID] = result
+	}
+	if (passed && !result.completed) || score > result.best {
+		result.improvedAt = at
+	}
+	result.completed = result.completed || passed
+	result.best = max(result.best, score)
+	l.place(u)
+	l.mu.Unlock()
+
+	if renamed {
+		return l.settings.SaveUsername(ctx, user.ID, user.Username)
+	}
+	return nil
+}
+
+// Settings возвращает настройки ученика
+func (l *Leaderboard) Settings(userID string) Settings {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if u, ok := l.users[userID]; ok {
+		return u.settings
+	}
+	return Settings{UserID: userID}
+}
+
+// UpdateSettings сохраняет настройки и сразу переставляет ученика в таблицах
+func (l *Leaderboard) UpdateSettings(ctx context.Context, settings Settings) (Settings, error) {
+	settings.Name = strings.TrimSpace(settings.Name)
+	settings.Group = strings.TrimSpace(settings.Group)
+	if utf8.RuneCountInString(settings.Name) > MaxNameLength || utf8.RuneCountInString(settings.Group) > MaxNameLength {
+		return Settings{}, ErrInvalidSettings
+	}
+	if err := l.settings.Save(ctx, settings); err != nil {
+		return Settings{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.user(settings.UserID)
+	u.settings = settings
+	l.place(u)
+	return settings, nil
+}
+
+// Page возвращает limit строк таблицы с offset. me - ID запросившего
+func (l *Leaderboard) Page(scope Scope, offset, limit int, me string) Page {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	board := l.boards[scope.key()]
+	page := Page{Scope: scope.key(), Total: len(board), Offset: offset, Rows: []Row{}}
+	for i := offset; i < len(board) && i < offset+limit; i++ {
+		page.Rows = append(page.Rows, row(board, i, me))
+	}
+	if i := position(board, me); i >= 0 {
+		mine := row(board, i, me)
+		page.Me = &mine
+	}
+	return page
+}
+
+// Around возвращает строки вокруг запросившего: radius выше и ниже.
+// false - его нет в таблице (не решал задачи трека или скрылся)
+func (l *Leaderboard) Around(scope Scope, me string, radius int) (Page, bool) {
+	l.mu.Lock()
+	i := position(l.boards[scope.key()], me)
+	l.mu.Unlock()
+	if i < 0 {
+		return Page{}, false
+	}
+	offset := max(i-radius, 0)
+	return l.Page(scope, offset, i-offset+radius+1, me), true
+}
+
+// user возвращает ученика, создавая его. Вызывается под mu
+func (l *Leaderboard) user(id string) *participant {
+	u, ok := l.users[id]
+	if !ok {
+		u = &participant{id: id, settings: Settings{UserID: id}, tasks: make(map[string]*taskResult)}
+		l.users[id] = u
+	}
+	return u
+}
+
+// place убирает ученика из его таблиц и ставит на новые места.
+// Вызывается под mu
+func (l *Leaderboard) place(u *participant) {
+	for _, key := range u.boards {
+		l.boards[key] = remove(l.boards[key], u)
+	}
+	u.boards = nil
+	if u.settings.Hidden || len(u.tasks) == 0 {
+		return
+	}
+
+	tracks := make(map[string]bool)
+	for taskID := range u.tasks {
+		tracks[l.topics[taskID]] = true
+	}
+	scopes := []Scope{{}}
+	for track := range tracks {
+		if track != "" {
+			scopes = append(scopes, Scope{Track: track})
+		}
+	}
+	if u.settings.Group != "" {
+		scopes = append(scopes, Scope{Group: u.settings.Group})
+	}
+	for _, scope := range scopes {
+		key := scope.key()
+		l.boards[key] = insert(l.boards[key], l.standing(u, scope.Track))
+		u.boards = append(u.boards, key)
+	}
+}
+
+// standing считает итог ученика по задачам трека (пусто - по всем)
+func (l *Leaderboard) standing(u *participant, track string) *standing {
+	s := &standing{user: u}
+	for taskID, result := range u.tasks {
+		if track != "" && l.topics[taskID] != track {
+			continue
+		}
+		if result.completed {
+			s.solved++
+		}
+		s.score += result.best
+		if result.improvedAt.After(s.improvedAt) {
+			s.improvedAt = result.improvedAt
+		}
+	}
+	return s
+}
+
+// better - стоит ли a выше b. Без улучшений - в конце, при равенстве
+// порядок задает ID, чтобы таблица не прыгала
+func better(a, b *standing) bool {
+	if a.solved != b.solved {
+		return a.solved > b.solved
+	}
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if !a.improvedAt.Equal(b.improvedAt) {
+		if a.improvedAt.IsZero() || b.improvedAt.IsZero() {
+			return b.improvedAt.IsZero()
+		}
+		return a.improvedAt.Before(b.improvedAt)
+	}
+	return a.user.id < b.user.id
+}
+
+// tied - одинаковое место
+func tied(a, b *standing) bool {
+	return a.solved == b.solved && a.score == b.score && a.improvedAt.Equal(b.improvedAt)
+}
+
+func insert(board []*standing, s *standing) []*standing {
+	i := sort.Search(len(board), func(i int) bool { return better(s, board[i]) })
+	board = append(board, nil)
+	copy(board[i+1:], board[i:])
+	board[i] = s
+	return board
+}
+
+func remove(board []*standing, u *participant) []*standing {
+	if i := position(board, u.id); i >= 0 {
+		return append(board[:i], board[i+1:]...)
+	}
+	return board
+}
+
+func position(board []*standing, userID string) int {
+	for i, s := range board {
+		if s.user.id == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// row - строка таблицы. Равные итоги делят место: 1, 2, 2, 4
+func row(board []*standing, i int, me string) Row {
+	s := board[i]
+	rank := i
+	for rank > 0 && tied(board[rank-1], s) {
+		rank--
+	}
+	r := Row{
+		Rank:   rank + 1,
+		Name:   s.user.name(),
+		Solved: s.solved,
+		Score:  s.score,
+		Me:     s.user.id == me,
+	}
+	if !s.improvedAt.IsZero() {
+		improvedAt := s.improvedAt
+		r.ImprovedAt = &improvedAt
+	}
+	return r
+}
+
+func (u *participant) name() string {
+	switch {
+	case u.settings.Name != "":
+		return u.settings.Name
+	case u.username != "":
+		return u.username
+	}
+	return "Участник"
+}
@@ -0,0 +1,268 @@
+package leaderboard
+
+import (
+	"backend/internal/models"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func at(minutes int) time.Time {
+	return t0.Add(time.Duration(minutes) * time.Minute)
+}
+
+func st(id string, solved int, score float64, improvedAt time.Time) *standing {
+	return &standing{user: &participant{id: id}, solved: solved, score: score, improvedAt: improvedAt}
+}
+
+// ranking - ID по порядку и места строк таблицы
+func ranking(board []*standing) ([]string, []int) {
+	var ids []string
+	var ranks []int
+	for i, s := range board {
+		ids = append(ids, s.user.id)
+		ranks = append(ranks, row(board, i, "").Rank)
+	}
+	return ids, ranks
+}
+
+func TestInsertRanking(t *testing.T) {
+	tests := []struct {
+		name      string
+		standings []*standing
+		wantIDs   []string
+		wantRanks []int
+	}{
+		{
+			name:      "more solved tasks first",
+			standings: []*standing{st("a", 1, 1, at(0)), st("b", 2, 1.5, at(5))},
+			wantIDs:   []string{"b", "a"},
+			wantRanks: []int{1, 2},
+		},
+		{
+			name:      "score breaks a solved tie",
+			standings: []*standing{st("a", 1, 1.5, at(0)), st("b", 1, 1.8, at(5))},
+			wantIDs:   []string{"b", "a"},
+			wantRanks: []int{1, 2},
+		},
+		{
+			name:      "earlier improvement wins",
+			standings: []*standing{st("a", 1, 1, at(5)), st("b", 1, 1, at(0))},
+			wantIDs:   []string{"b", "a"},
+			wantRanks: []int{1, 2},
+		},
+		{
+			name:      "no improvement goes last",
+			standings: []*standing{st("a", 0, 0, time.Time{}), st("b", 0, 0, at(5))},
+			wantIDs:   []string{"b", "a"},
+			wantRanks: []int{1, 2},
+		},
+		{
+			name: "ties share a rank",
+			standings: []*standing{
+				st("c", 1, 1, at(0)), st("d", 0, 0.5, at(1)), st("a", 1, 1, at(0)), st("b", 1, 1, at(0)),
+			},
+			wantIDs:   []string{"a", "b", "c", "d"},
+			wantRanks: []int{1, 1, 1, 4},
+		},
+		{
+			name: "tie in the middle",
+			standings: []*standing{
+				st("a", 3, 3, at(0)), st("b", 2, 2, at(1)), st("c", 2, 2, at(1)), st("d", 1, 1, at(2)),
+			},
+			wantIDs:   []string{"a", "b", "c", "d"},
+			wantRanks: []int{1, 2, 2, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Порядок вставки не влияет на таблицу
+			for _, order := range []string{"forward", "reverse"} {
+				standings := slices.Clone(tt.standings)
+				if order == "reverse" {
+					slices.Reverse(standings)
+				}
+				var board []*standing
+				for _, s := range standings {
+					board = insert(board, s)
+				}
+				ids, ranks := ranking(board)
+				if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(ranks, tt.wantRanks) {
+					t.Errorf("%s: got %v ranks %v, want %v ranks %v", order, ids, ranks, tt.wantIDs, tt.wantRanks)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveRanking(t *testing.T) {
+	board := func() []*standing {
+		var board []*standing
+		for _, s := range []*standing{st("a", 2, 2, at(0)), st("b", 1, 1, at(1)), st("c", 1, 1, at(1)), st("d", 0, 0, time.Time{})} {
+			board = insert(board, s)
+		}
+		return board
+	}
+	tests := []struct {
+		name      string
+		remove    string
+		wantIDs   []string
+		wantRanks []int
+	}{
+		{name: "leader", remove: "a", wantIDs: []string{"b", "c", "d"}, wantRanks: []int{1, 1, 3}},
+		{name: "one of a tie", remove: "b", wantIDs: []string{"a", "c", "d"}, wantRanks: []int{1, 2, 3}},
+		{name: "last", remove: "d", wantIDs: []string{"a", "b", "c"}, wantRanks: []int{1, 2, 2}},
+		{name: "not on the board", remove: "x", wantIDs: []string{"a", "b", "c", "d"}, wantRanks: []int{1, 2, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, ranks := ranking(remove(board(), &participant{id: tt.remove}))
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(ranks, tt.wantRanks) {
+				t.Errorf("got %v ranks %v, want %v ranks %v", ids, ranks, tt.wantIDs, tt.wantRanks)
+			}
+		})
+	}
+}
+
+// check - проверка одного ученика
+type check struct {
+	user   string
+	task   string
+	passed bool
+	score  float64
+	at     time.Time
+}
+
+func names(page Page) []string {
+	var names []string
+	for _, r := range page.Rows {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestLeaderboardRecord(t *testing.T) {
+	topics := map[string]string{"1": "basics", "2": "functions", "3": "functions"}
+	tests := []struct {
+		name     string
+		checks   []check
+		settings []Settings
+		scope    Scope
+		want     []string
+	}{
+		{
+			name: "incremental update moves the user up",
+			checks: []check{
+				{"alice", "1", true, 1, at(0)},
+				{"bob", "2", false, 0.5, at(1)},
+				{"bob", "2", true, 1, at(2)},
+				{"bob", "3", true, 1, at(3)},
+			},
+			want: []string{"bob", "alice"},
+		},
+		{
+			name: "a worse attempt does not change the improvement time",
+			checks: []check{
+				{"alice", "1", true, 1, at(0)},
+				{"bob", "1", true, 1, at(1)},
+				{"alice", "1", false, 0, at(2)},
+			},
+			want: []string{"alice", "bob"},
+		},
+		{
+			name: "track board has only users with results in the track",
+			checks: []check{
+				{"alice", "1", true, 1, at(0)},
+				{"bob", "2", false, 0.5, at(1)},
+			},
+			scope: Scope{Track: "functions"},
+			want:  []string{"bob"},
+		},
+		{
+			name: "group board and display name",
+			checks: []check{
+				{"alice", "1", true, 1, at(0)},
+				{"bob", "1", true, 1, at(1)},
+				{"carol", "1", true, 1, at(2)},
+			},
+			settings: []Settings{{UserID: "alice", Group: "9b"}, {UserID: "carol", Name: "Кэрол", Group: "9b"}},
+			scope:    Scope{Group: "9b"},
+			want:     []string{"alice", "Кэрол"},
+		},
+		{
+			name: "hidden users are not shown",
+			checks: []check{
+				{"alice", "1", true, 1, at(0)},
+				{"bob", "1", true, 1, at(1)},
+			},
+			settings: []Settings{{UserID: "alice", Hidden: true}},
+			want:     []string{"bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := New(topics, NewMemorySettingsStore())
+			for _, c := range tt.checks {
+				if err := board.Record(context.Background(), &models.User{ID: c.user, Username: c.user}, c.task, c.passed, c.score, c.at); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, s := range tt.settings {
+				if _, err := board.UpdateSettings(context.Background(), s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			page := board.Page(tt.scope, 0, 10, "")
+			if got := names(page); !slices.Equal(got, tt.want) || page.Total != len(tt.want) {
+				t.Errorf("board = %v (total %d), want %v", got, page.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaderboardLoad(t *testing.T) {
+	topics := map[string]string{"1": "basics"}
+	settings := NewMemorySettingsStore()
+	before := New(topics, settings)
+	var records []models.UserProgress
+	for i, id := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		user := &models.User{ID: id, Username: "Гость_" + id}
+		if err := before.Record(context.Background(), user, "1", true, 1, at(i)); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, models.UserProgress{UserID: id, TaskID: "1", Completed: true, Attempts: 1, BestScore: 1, ImprovedAt: at(i)})
+	}
+	if _, err := before.UpdateSettings(context.Background(), Settings{UserID: "u2", Name: "Вторая"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// После перезапуска пользователей в памяти нет, имена берутся из настроек
+	saved, err := settings.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := New(topics, settings)
+	after.Load(records, saved)
+
+	want := []string{"Гость_u1", "Вторая", "Гость_u3", "Гость_u4", "Гость_u5"}
+	if got := names(after.Page(Scope{}, 0, 10, "")); !slices.Equal(got, want) {
+		t.Errorf("board after restart = %v, want %v", got, want)
+	}
+
+	page, ok := after.Around(Scope{}, "u3", 1)
+	if !ok {
+		t.Fatal("u3 is not on the board")
+	}
+	if got := names(page); page.Offset != 1 || !slices.Equal(got, want[1:4]) {
+		t.Errorf("around u3 = %v at %d, want %v at 1", got, page.Offset, want[1:4])
+	}
+	if page.Me == nil || page.Me.Rank != 3 {
+		t.Errorf("my row = %+v, want rank 3", page.Me)
+	}
+	if _, ok := after.Around(Scope{}, "nobody", 1); ok {
+		t.Error("a user without progress is on the board")
+	}
+}
@@ -0,0 +1,46 @@
+package leaderboard
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySettingsStore - настройки в памяти процесса, когда база не настроена
+type MemorySettingsStore struct {
+	mu       sync.Mutex
+	settings map[string]Settings
+}
+
+// NewMemorySettingsStore создает пустое хранилище
+func NewMemorySettingsStore() *MemorySettingsStore {
+	return &MemorySettingsStore{settings: make(map[string]Settings)}
+}
+
+func (s *MemorySettingsStore) List(ctx context.Context) ([]Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := make([]Settings, 0, len(s.settings))
+	for _, item := range s.settings {
+		settings = append(settings, item)
+	}
+	return settings, nil
+}
+
+func (s *MemorySettingsStore) Save(ctx context.Context, settings Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings.Username = s.settings[settings.UserID].Username
+	s.settings[settings.UserID] = settings
+	return nil
+}
+
+func (s *MemorySettingsStore) SaveUsername(ctx context.Context, userID, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings := s.settings[userID]
+	settings.UserID = userID
+	settings.Username = username
+	s.settings[userID] = settings
+	return nil
+}
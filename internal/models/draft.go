@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Draft - несданный код ученика по задаче на одном языке
+type Draft struct {
+	TaskID   string       `json:"task_id"`
+	Language string       `json:"language"`
+	Code     string       `json:"code"`
+	Files    []SourceFile `json:"files,omitempty"`
+	// Version растет на 1 с каждым сохранением, по нему ловятся конфликты
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DraftRevision - одно из последних сохранений черновика
+type DraftRevision struct {
+	Version int          `json:"version"`
+	Code    string       `json:"code"`
+	Files   []SourceFile `json:"files,omitempty"`
+	SavedAt time.Time    `json:"saved_at"`
+}
@@ -4,16 +4,50 @@ import "time"
 
 // ExecutionResult представляет результат выполнения кода
 type ExecutionResult struct {
-	ID            string        `json:"id"`              // Уникальный номер попытки
-	TaskID        string        `json:"task_id"`         // ID задания
-	UserID        string        `json:"user_id"`         // ID пользователя
-	Code          string        `json:"code"`            // Сам код который выполнили
-	Language      string        `json:"language"`        // Язык программирования
-	Output        string        `json:"output"`          // Что программа напечатала
-	Success       bool          `json:"success"`         // Успешно или с ошибкой
-	Error         string        `json:"error,omitempty"` // Текст ошибки (если была)
-	ExecutionTime time.Duration `json:"execution_time"`
-	CreatedAt     time.Time     `json:"created_at"`
+	ID            string         `json:"id"`              // Уникальный номер попытки
+	TaskID        string         `json:"task_id"`         // ID задания
+	UserID        string         `json:"user_id"`         // ID пользователя
+	Code          string         `json:"code"`            // Сам код который выполнили
+	Language      string         `json:"language"`        // Язык программирования
+	Output        string         `json:"output"`          // Что программа напечатала
+	Success       bool           `json:"success"`         // Успешно или с ошибкой
+	Error         string         `json:"error,omitempty"` // Текст ошибки (если была)
+	Verdict       string         `json:"verdict,omitempty"`
+	ExecutionTime time.Duration  `json:"execution_time"` // Время работы программы по часам
+	Stats         ExecutionStats `json:"stats"`
+	Report        string         `json:"report,omitempty"`       // Отчет тестового фреймворка
+	ImageDigest   string         `json:"image_digest,omitempty"` // Образ Docker, в котором шел запуск
+	Backend       string         `json:"backend,omitempty"`      // Исполнитель: docker или local
+	Kind          string         `json:"kind,omitempty"`         // run - запуск, check - проверка решения
+	Message       string         `json:"message,omitempty"`      // Итог проверки
+	Tests         []TestResult   `json:"tests,omitempty"`        // Результаты юнит-тестов
+	Files         []SourceFile   `json:"files,omitempty"`        // Остальные файлы решения
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// Виды запусков в истории
+const (
+	KindRun   = "run"
+	KindCheck = "check"
+)
+
+// SubmissionSummary - посылка в списке истории, без кода и вывода
+type SubmissionSummary struct {
+	ID        string         `json:"id"`
+	TaskID    string         `json:"task_id"`
+	Language  string         `json:"language"`
+	Success   bool           `json:"success"`
+	Verdict   string         `json:"verdict,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Stats     ExecutionStats `json:"stats"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ExecutionStats замеры одного запуска программы. Нули - замер недоступен
+type ExecutionStats struct {
+	WallTimeMs   int64 `json:"wall_time_ms"`   // По часам
+	CPUTimeMs    int64 `json:"cpu_time_ms"`    // user + system
+	PeakMemoryKB int64 `json:"peak_memory_kb"` // Пиковая резидентная память
 }
 
 // DockerExecutionConfig конфигурация для Docker контейнера
@@ -26,11 +60,23 @@ type DockerExecutionConfig struct {
 	Env       map[string]string `json:"env"`
 }
 
-// LanguageConfig конфигурация для разных языков программирования
-type LanguageConfig struct {
-	DockerImage string        `json:"docker_image"`          // Какой образ использовать
-	CompileCmd  []string      `json:"compile_cmd,omitempty"` // Сколько памяти дать
-	RunCmd      []string      `json:"run_cmd"`               // Сколько процессора
-	FileName    string        `json:"file_name"`             // Максимальное время работы
-	Timeout     time.Duration `json:"timeout"`
-}
+// Вердикты выполнения и проверки
+const (
+	VerdictOK               = "OK"
+	VerdictCompilationError = "Compilation Error"
+	VerdictRuntimeError     = "Runtime Error"
+	VerdictTimeLimit        = "Time Limit Exceeded"
+	VerdictMemoryLimit      = "Memory Limit Exceeded"
+	VerdictOutputLimit      = "Output Limit Exceeded"
+	VerdictCancelled        = "Cancelled"
+	VerdictUnsupported      = "Unsupported Language"
+	VerdictWrongAnswer      = "Wrong Answer"
+	VerdictCheckerError     = "Checker Error"
+	VerdictSkipped          = "Skipped"
+)
+
+// Исполнители кода
+const (
+	BackendDocker = "docker"
+	BackendLocal  = "local"
+)
@@ -1,11 +1,75 @@
 package models
 
 type Task struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Template    string `json:"template"`
-	Tests       []Test `json:"tests"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Topic - тема задачи, по ней считается прогресс ученика
+	Topic       string     `json:"topic,omitempty"`
+	Description string     `json:"description"`
+	Template    string     `json:"template"`
+	Tests       []Test     `json:"tests"`
+	Limits      TaskLimits `json:"limits"`
+	// Checker - как сравнивать вывод с ожидаемым (пусто - точное совпадение)
+	Checker CheckerConfig `json:"checker"`
+	// Function - ученик пишет только эту функцию, тесты вызывают ее
+	// через обвязку (Input теста - аргументы через запятую в формате JSON)
+	Function *FunctionSignature `json:"function,omitempty"`
+	// Files - файлы задачи: заготовки модулей и данные. Кладутся рядом с
+	// решением только для чтения, скрытые в ответах API не отдаются
+	Files []TaskFile `json:"files,omitempty"`
+	// UnitTests - файл юнит-тестов учителя по id языка (в ответах API не отдается)
+	UnitTests map[string]string `json:"unit_tests,omitempty"`
+	// EffectiveLimits - лимиты с поправками по языкам (только в ответах API)
+	EffectiveLimits map[string]TaskLimits `json:"effective_limits,omitempty"`
+	// Status - solved или attempted для вошедшего ученика (только в ответах API)
+	Status string `json:"status,omitempty"`
+}
+
+// TaskLimits ограничения на запуск решения. Нулевое поле - значение по умолчанию
+type TaskLimits struct {
+	TimeLimitMs   int `json:"time_limit_ms,omitempty"`
+	MemoryLimitMB int `json:"memory_limit_mb,omitempty"`
+	OutputLimitKB int `json:"output_limit_kb,omitempty"`
+}
+
+// CheckerConfig способ проверки вывода решения
+type CheckerConfig struct {
+	// Mode: exact, tokens, case_insensitive, float, unordered_lines, words, custom
+	Mode string `json:"mode,omitempty"`
+	// Допустимая погрешность для float: абсолютная и относительная
+	AbsEpsilon float64 `json:"abs_epsilon,omitempty"`
+	RelEpsilon float64 `json:"rel_epsilon,omitempty"`
+	// Программа-чекер для custom: язык и исходный код (в ответах API не отдается)
+	Language string `json:"language,omitempty"`
+	Source   string `json:"source,omitempty"`
+}
+
+// FunctionSignature функция, которую пишет ученик.
+// Типы: int, long, float, string, bool и массивы из них (int[])
+type FunctionSignature struct {
+	Name    string  `json:"name"`
+	Params  []Param `json:"params"`
+	Returns string  `json:"returns"`
+}
+
+// Param параметр функции
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SourceFile файл решения. Path - относительный путь через /
+// (com/example/Util.java, include/util.h)
+type SourceFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// TaskFile файл задачи, который решение может только читать
+type TaskFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+	Hidden  bool   `json:"hidden,omitempty"` // данные тестов, ученику не показываем
 }
 
 type Test struct {
@@ -17,30 +81,56 @@ type ExecutionRequest struct {
 	TaskID   string `json:"task_id"`
 	Code     string `json:"code"`
 	Language string `json:"language"`
+	// Files - остальные файлы решения (основной файл можно передать здесь вместо Code)
+	Files []SourceFile `json:"files,omitempty"`
 }
 
 type ExecutionResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Output  string `json:"output"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Output  string          `json:"output"`
+	Verdict string          `json:"verdict,omitempty"`
+	Stats   *ExecutionStats `json:"stats,omitempty"`
+	// ImageDigest - образ Docker запуска (пусто - запуск локальный)
+	ImageDigest string `json:"image_digest,omitempty"`
+	// Backend - исполнитель запуска: docker или local
+	Backend string `json:"backend,omitempty"`
+	// Report - отчет тестового фреймворка для проверки юнит-тестами
+	Report string `json:"-"`
 }
 
 // CheckRequest - запрос на проверку решения
 type CheckRequest struct {
-	TaskID   interface{} `json:"task_id"` // ← принимает и строки и числа
-	Code     string      `json:"code"`
-	Language string      `json:"language"`
-	Tests    []Test      `json:"tests,omitempty"`
+	TaskID   interface{}  `json:"task_id"` // ← принимает и строки и числа
+	Code     string       `json:"code"`
+	Language string       `json:"language"`
+	Tests    []Test       `json:"tests,omitempty"`
+	Files    []SourceFile `json:"files,omitempty"`
 }
 
 // CheckResponse - ответ проверки решения
 type CheckResponse struct {
-	Success  bool   `json:"success"`
-	Passed   bool   `json:"passed"`
-	Output   string `json:"output"`
-	Expected string `json:"expected,omitempty"`
-	Actual   string `json:"actual,omitempty"`
-	Message  string `json:"message"`
+	Success  bool            `json:"success"`
+	Passed   bool            `json:"passed"`
+	Output   string          `json:"output"`
+	Expected string          `json:"expected,omitempty"`
+	Actual   string          `json:"actual,omitempty"`
+	Message  string          `json:"message"`
+	Verdict  string          `json:"verdict,omitempty"`
+	Stats    *ExecutionStats `json:"stats,omitempty"`
+	Tests    []TestResult    `json:"tests,omitempty"` // По тестам (юнит-тесты)
+	// ImageDigest - образ Docker запуска (пусто - запуск локальный)
+	ImageDigest string `json:"image_digest,omitempty"`
+	// Backend - исполнитель запуска: docker или local
+	Backend string `json:"backend,omitempty"`
+}
+
+// TestResult результат одного юнит-теста
+type TestResult struct {
+	Name    string `json:"name"`
+	Verdict string `json:"verdict"` // OK, Wrong Answer, Runtime Error, Skipped
+	Message string `json:"message,omitempty"`
+	TimeMs  int64  `json:"time_ms,omitempty"`
 }
 
 // CheckResult - результат проверки
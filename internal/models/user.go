@@ -19,6 +19,8 @@ type UserProgress struct {
 	Attempts    int       `json:"attempts"`
 	BestScore   float64   `json:"best_score"`
 	LastAttempt time.Time `json:"last_attempt"`
+	// ImprovedAt - когда задача была решена или улучшен балл (ноль - не улучшался)
+	ImprovedAt time.Time `json:"improved_at"`
 }
 
 // AuthRequest представляет запрос аутентификации
@@ -0,0 +1,70 @@
+package progress
+
+import (
+	"backend/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore - прогресс в памяти процесса, когда база не настроена.
+// Пропадает при перезапуске, как и гостевые сессии
+type MemoryStore struct {
+	mu    sync.Mutex
+	users map[string]map[string]*models.UserProgress
+}
+
+// NewMemoryStore создает пустое хранилище
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[string]map[string]*models.UserProgress)}
+}
+
+func (s *MemoryStore) Record(ctx context.Context, userID, taskID string, passed bool, score float64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, ok := s.users[userID]
+	if !ok {
+		tasks = make(map[string]*models.UserProgress)
+		s.users[userID] = tasks
+	}
+	record, ok := tasks[taskID]
+	if !ok {
+		record = &models.UserProgress{UserID: userID, TaskID: taskID}
+		tasks[taskID] = record
+	}
+	if (passed && !record.Completed) || score > record.BestScore {
+		record.ImprovedAt = at
+	}
+	record.Attempts++
+	record.Completed = record.Completed || passed
+	record.BestScore = max(record.BestScore, score)
+	record.LastAttempt = at
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, userID string) ([]models.UserProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []models.UserProgress{}
+	for _, record := range s.users[userID] {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].TaskID < records[j].TaskID })
+	return records, nil
+}
+
+func (s *MemoryStore) All(ctx context.Context) ([]models.UserProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []models.UserProgress{}
+	for _, tasks := range s.users {
+		for _, record := range tasks {
+			records = append(records, *record)
+		}
+	}
+	return records, nil
+}
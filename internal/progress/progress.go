@@ -0,0 +1,156 @@
+package progress
+
+import (
+	"backend/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// Прогресс ученика по задачам: каждая проверенная посылка увеличивает
+// attempts, запоминает лучший балл и отмечает задачу решенной.
+// Хранится в таблице user_progress, без базы - в памяти процесса
+
+// Статусы задачи для ученика
+const (
+	StatusSolved     = "solved"
+	StatusAttempted  = "attempted"
+	StatusNotStarted = "not_started"
+)
+
+// Store - хранилище прогресса
+type Store interface {
+	// Record учитывает посылку: passed - задача решена, score - доля пройденных тестов
+	Record(ctx context.Context, userID, taskID string, passed bool, score float64, at time.Time) error
+	// List возвращает прогресс ученика по всем задачам, которые он пробовал
+	List(ctx context.Context, userID string) ([]models.UserProgress, error)
+	// All возвращает прогресс всех учеников
+	All(ctx context.Context) ([]models.UserProgress, error)
+}
+
+// Judged - считается ли посылка с таким вердиктом попыткой. Отмененные,
+// неподдерживаемые и сломанные проверкой посылки ученик не проваливал
+func Judged(verdict string) bool {
+	switch verdict {
+	case "", models.VerdictCancelled, models.VerdictUnsupported, models.VerdictCheckerError:
+		return false
+	}
+	return true
+}
+
+// Score - балл посылки от 0 до 1: решенная задача - 1, для юнит-тестов -
+// доля пройденных тестов, иначе 0
+func Score(response models.CheckResponse) float64 {
+	if response.Passed {
+		return 1
+	}
+	if len(response.Tests) == 0 {
+		return 0
+	}
+	passed := 0
+	for _, test := range response.Tests {
+		if test.Verdict == models.VerdictOK {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(response.Tests))
+}
+
+// Status - статус задачи по записи прогресса (nil - задачу не пробовали)
+func Status(record *models.UserProgress) string {
+	switch {
+	case record == nil || record.Attempts == 0:
+		return StatusNotStarted
+	case record.Completed:
+		return StatusSolved
+	}
+	return StatusAttempted
+}
+
+// TaskProgress - прогресс по одной задаче
+type TaskProgress struct {
+	TaskID      string     `json:"task_id"`
+	Title       string     `json:"title"`
+	Topic       string     `json:"topic,omitempty"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	BestScore   float64    `json:"best_score"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+}
+
+// Completion - сводка по группе задач
+type Completion struct {
+	Topic     string  `json:"topic,omitempty"`
+	Total     int     `json:"total"`
+	Solved    int     `json:"solved"`
+	Attempted int     `json:"attempted"`  // пробовал, но не решил
+	Percent   float64 `json:"completion"` // доля решенных, от 0 до 100
+}
+
+// Summary - ответ /api/me/progress
+type Summary struct {
+	UserID  string         `json:"user_id"`
+	Tasks   []TaskProgress `json:"tasks"`
+	Topics  []Completion   `json:"topics"`
+	Overall Completion     `json:"overall"`
+}
+
+// Summarize сводит прогресс ученика по задачам и темам. Записи о задачах,
+// которых больше нет, не учитываются
+func Summarize(userID string, tasks []models.Task, records []models.UserProgress) Summary {
+	byTask := make(map[string]*models.UserProgress, len(records))
+	for i := range records {
+		byTask[records[i].TaskID] = &records[i]
+	}
+
+	summary := Summary{UserID: userID, Tasks: []TaskProgress{}, Topics: []Completion{}}
+	topics := make(map[string]*Completion)
+	for _, task := range tasks {
+		record := byTask[task.ID]
+		item := TaskProgress{
+			TaskID: task.ID,
+			Title:  task.Title,
+			Topic:  task.Topic,
+			Status: Status(record),
+		}
+		if record != nil {
+			item.Attempts = record.Attempts
+			item.BestScore = record.BestScore
+			if !record.LastAttempt.IsZero() {
+				lastAttempt := record.LastAttempt
+				item.LastAttempt = &lastAttempt
+			}
+		}
+		summary.Tasks = append(summary.Tasks, item)
+
+		topic, ok := topics[task.Topic]
+		if !ok {
+			topic = &Completion{Topic: task.Topic}
+			topics[task.Topic] = topic
+		}
+		for _, completion := range []*Completion{topic, &summary.Overall} {
+			completion.Total++
+			switch item.Status {
+			case StatusSolved:
+				completion.Solved++
+			case StatusAttempted:
+				completion.Attempted++
+			}
+		}
+	}
+
+	for _, topic := range topics {
+		topic.Percent = percent(topic.Solved, topic.Total)
+		summary.Topics = append(summary.Topics, *topic)
+	}
+	sort.Slice(summary.Topics, func(i, j int) bool { return summary.Topics[i].Topic < summary.Topics[j].Topic })
+	summary.Overall.Percent = percent(summary.Overall.Solved, summary.Overall.Total)
+	return summary
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part*1000/total) / 10
+}
@@ -0,0 +1,67 @@
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Песочница для локального выполнения недоверенного кода (только Linux).
+// Каждый процесс запускается через вспомогательный процесс (сам сервер,
+// перезапущенный с argv[0] = initArg), который в новых namespace'ах
+// монтирует приватный /tmp, ставит setrlimit, сбрасывает права до
+// отдельного UID, включает seccomp-фильтр и только потом делает exec программы.
+// Память считается через cgroup v2, если она делегирована серверу
+
+// initArg - argv[0] вспомогательного процесса
+const initArg = "sandbox-init"
+
+// specEnv - переменная окружения, через которую передается Spec
+const specEnv = "TRENAGER_SANDBOX_SPEC"
+
+// ErrUnsupported - песочница недоступна на этой платформе
+var ErrUnsupported = errors.New("sandbox is only supported on Linux")
+
+// Config настройки песочницы
+type Config struct {
+	Enabled    bool
+	UIDBase    int    // первый UID из пула
+	UIDCount   int    // сколько UID в пуле (= сколько запусков одновременно)
+	CgroupRoot string // родительская cgroup v2 для запусков
+	TmpSizeMB  int    // размер приватного /tmp
+	Limits     Limits // лимиты по умолчанию для запуска программ
+}
+
+// Limits ограничения одного процесса. Нулевое значение - без ограничения
+type Limits struct {
+	CPUSeconds   int // RLIMIT_CPU
+	AddressMB    int // RLIMIT_AS
+	FileSizeMB   int // RLIMIT_FSIZE
+	MaxProcesses int // RLIMIT_NPROC и pids.max
+	MemoryMB     int // memory.max в cgroup
+}
+
+// Stats - что удалось узнать о процессе после завершения
+type Stats struct {
+	PeakMemoryBytes int64
+	OOMKilled       bool
+}
+
+// Run - подготовленный к запуску процесс в песочнице.
+// Cmd настраивается вызывающим (Stdout, Stderr), после Wait нужно вызвать Close
+type Run struct {
+	Cmd *exec.Cmd
+
+	uid      int
+	cgroup   string
+	cgroupFD int
+	release  func()
+}
+
+// Spec - то, что родитель передает вспомогательному процессу
+type Spec struct {
+	WorkDir   string   `json:"work_dir"`
+	UID       int      `json:"uid"`
+	TmpSizeMB int      `json:"tmp_size_mb"`
+	Limits    Limits   `json:"limits"`
+	Args      []string `json:"args"`
+}
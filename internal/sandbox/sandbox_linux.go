@@ -0,0 +1,417 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Sandbox запускает процессы в изолированном окружении
+type Sandbox struct {
+	cfg     Config
+	uids    chan int // свободные UID
+	cgroups bool     // удалось ли подготовить cgroup v2
+}
+
+// New проверяет, что песочница работает, и готовит пул UID и cgroup.
+// Сервер должен быть запущен от root
+func New(cfg Config) (*Sandbox, error) {
+	if !seccompSupported {
+		return nil, fmt.Errorf("sandbox: seccomp filter is not implemented for %s", runtime.GOARCH)
+	}
+	if os.Geteuid() != 0 {
+		return nil, errors.New("sandbox: server must run as root to create namespaces and switch UIDs")
+	}
+
+	if cfg.UIDBase <= 0 {
+		cfg.UIDBase = 200000
+	}
+	if cfg.UIDCount <= 0 {
+		cfg.UIDCount = 64
+	}
+	if cfg.TmpSizeMB <= 0 {
+		cfg.TmpSizeMB = 64
+	}
+
+	s := &Sandbox{
+		cfg:  cfg,
+		uids: make(chan int, cfg.UIDCount),
+	}
+	for i := 0; i < cfg.UIDCount; i++ {
+		s.uids <- cfg.UIDBase + i
+	}
+
+	if cfg.CgroupRoot != "" {
+		if err := setupCgroupRoot(cfg.CgroupRoot); err != nil {
+			log.Printf("⚠️ Sandbox: cgroup v2 memory accounting disabled: %v", err)
+		} else {
+			s.cgroups = true
+		}
+	}
+
+	if err := s.selfCheck(); err != nil {
+		return nil, fmt.Errorf("sandbox self-check failed: %w", err)
+	}
+
+	log.Printf("🔒 Sandbox enabled: UIDs %d-%d, cgroups=%t", cfg.UIDBase, cfg.UIDBase+cfg.UIDCount-1, s.cgroups)
+	return s, nil
+}
+
+// DefaultLimits - лимиты для запуска программ из конфигурации
+func (s *Sandbox) DefaultLimits() Limits {
+	return s.cfg.Limits
+}
+
+// selfCheck запускает true в песочнице: namespaces могут быть запрещены
+// (например, внутри непривилегированного контейнера)
+func (s *Sandbox) selfCheck() error {
+	dir, err := os.MkdirTemp("", "sandbox_check_*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	run, err := s.Command(ctx, dir, Limits{CPUSeconds: 5, MaxProcesses: 8}, nil, "true")
+	if err != nil {
+		return err
+	}
+	defer run.Close()
+
+	var stderr bytes.Buffer
+	run.Cmd.Stderr = &stderr
+	if err := run.Cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Command готовит запуск name с аргументами в workDir под отдельным UID.
+// workDir передается во владение этому UID. env дополняет минимальное окружение
+func (s *Sandbox) Command(ctx context.Context, workDir string, limits Limits, env []string, name string, args ...string) (*Run, error) {
+	var uid int
+	select {
+	case uid = <-s.uids:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	run := &Run{uid: uid, release: func() { s.uids <- uid }}
+
+	if err := chownTree(workDir, uid); err != nil {
+		run.Close()
+		return nil, fmt.Errorf("failed to prepare work dir: %w", err)
+	}
+
+	spec, err := json.Marshal(Spec{
+		WorkDir:   workDir,
+		UID:       uid,
+		TmpSizeMB: s.cfg.TmpSizeMB,
+		Limits:    limits,
+		Args:      append([]string{name}, args...),
+	})
+	if err != nil {
+		run.Close()
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe")
+	cmd.Args = []string{initArg}
+	cmd.Dir = workDir
+	// Окружение сервера (DATABASE_URL и прочее) в песочницу не передаем
+	cmd.Env = append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=/tmp",
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+		specEnv + "=" + string(spec),
+	}, env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		Pdeathsig: syscall.SIGKILL,
+	}
+
+	if s.cgroups {
+		dir := filepath.Join(s.cfg.CgroupRoot, fmt.Sprintf("run-%d-%d", uid, time.Now().UnixNano()))
+		fd, err := createRunCgroup(dir, limits)
+		if err != nil {
+			run.Close()
+			return nil, fmt.Errorf("failed to create cgroup: %w", err)
+		}
+		run.cgroup = dir
+		run.cgroupFD = fd
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = fd
+	}
+
+	run.Cmd = cmd
+	return run, nil
+}
+
+// Stats читает пиковую память и OOM из cgroup запуска
+func (r *Run) Stats() Stats {
+	var stats Stats
+	if r.cgroup == "" {
+		return stats
+	}
+
+	if data, err := os.ReadFile(filepath.Join(r.cgroup, "memory.peak")); err == nil {
+		stats.PeakMemoryBytes, _ = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	}
+	if data, err := os.ReadFile(filepath.Join(r.cgroup, "memory.events")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "oom_kill" && fields[1] != "0" {
+				stats.OOMKilled = true
+			}
+		}
+	}
+	return stats
+}
+
+// Close удаляет cgroup запуска и возвращает UID в пул
+func (r *Run) Close() {
+	if r.cgroupFD > 0 {
+		syscall.Close(r.cgroupFD)
+		r.cgroupFD = 0
+	}
+	if r.cgroup != "" {
+		// Ядро может еще не убрать завершившиеся процессы из cgroup
+		for i := 0; i < 10; i++ {
+			if err := os.Remove(r.cgroup); err == nil || os.IsNotExist(err) {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		r.cgroup = ""
+	}
+	if r.release != nil {
+		r.release()
+		r.release = nil
+	}
+}
+
+// CPULimitExceeded - процесс убит ядром за превышение RLIMIT_CPU.
+// Программа в песочнице - init своего PID namespace и SIGXCPU игнорирует,
+// поэтому ее добивает SIGKILL на жестком лимите
+func CPULimitExceeded(state *os.ProcessState, limits Limits) bool {
+	if state == nil || limits.CPUSeconds <= 0 {
+		return false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return false
+	}
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return true
+	case syscall.SIGKILL:
+		return state.UserTime()+state.SystemTime() >= time.Duration(limits.CPUSeconds)*time.Second
+	}
+	return false
+}
+
+// chownTree отдает дерево во владение uid. Файлы без прав на запись
+// остаются у root, чтобы программа не могла сделать их записываемыми
+func chownTree(root string, uid int) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && info.Mode().Perm()&0222 == 0 {
+			return nil
+		}
+		return os.Lchown(path, uid, uid)
+	})
+}
+
+// setupCgroupRoot создает родительскую cgroup и включает в ней контроллеры memory и pids
+func setupCgroupRoot(root string) error {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return errors.New("cgroup v2 is not mounted")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, "cgroup.subtree_control"), []byte("+memory +pids"), 0644)
+}
+
+// createRunCgroup создает cgroup одного запуска и возвращает ее дескриптор для clone3
+func createRunCgroup(dir string, limits Limits) (int, error) {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	settings := map[string]string{}
+	if limits.MemoryMB > 0 {
+		settings["memory.max"] = strconv.Itoa(limits.MemoryMB * 1024 * 1024)
+		// memory.swap.max есть только при включенном учете swap
+		if _, err := os.Stat(filepath.Join(dir, "memory.swap.max")); err == nil {
+			settings["memory.swap.max"] = "0"
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		settings["pids.max"] = strconv.Itoa(limits.MaxProcesses)
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			os.Remove(dir)
+			return 0, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	fd, err := syscall.Open(dir, syscall.O_DIRECTORY|syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		os.Remove(dir)
+		return 0, err
+	}
+	return fd, nil
+}
+
+// init во вспомогательном процессе песочницы настраивает окружение и делает exec программы.
+// Выполняется раньше init() пакетов, импортирующих sandbox (handlers создает Docker клиент),
+// поэтому дочерний процесс не успевает сделать ничего лишнего
+func init() {
+	if len(os.Args) == 0 || os.Args[0] != initArg {
+		return
+	}
+
+	if err := initChild(); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+func initChild() error {
+	// seccomp и no_new_privs ставятся на поток, exec должен быть из него же
+	runtime.LockOSThread()
+
+	var spec Spec
+	if err := json.Unmarshal([]byte(os.Getenv(specEnv)), &spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+	os.Unsetenv(specEnv)
+	if len(spec.Args) == 0 {
+		return errors.New("no command")
+	}
+
+	if err := setupMounts(spec); err != nil {
+		return err
+	}
+
+	path, err := exec.LookPath(spec.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := setRlimits(spec.Limits); err != nil {
+		return err
+	}
+
+	if err := unix.Setgroups(nil); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := unix.Setresgid(spec.UID, spec.UID, spec.UID); err != nil {
+		return fmt.Errorf("setgid: %w", err)
+	}
+	if err := unix.Setresuid(spec.UID, spec.UID, spec.UID); err != nil {
+		return fmt.Errorf("setuid: %w", err)
+	}
+
+	if err := os.Chdir(spec.WorkDir); err != nil {
+		return err
+	}
+
+	if err := installSeccomp(); err != nil {
+		return fmt.Errorf("seccomp: %w", err)
+	}
+
+	return unix.Exec(path, spec.Args, os.Environ())
+}
+
+// setupMounts делает приватный /tmp. Рабочая директория обычно лежит в /tmp,
+// поэтому до монтирования берем на нее дескриптор и потом монтируем обратно по тому же пути
+func setupMounts(spec Spec) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+
+	workFD, err := unix.Open(spec.WorkDir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open work dir: %w", err)
+	}
+	defer unix.Close(workFD)
+
+	options := fmt.Sprintf("size=%dm,mode=1777", spec.TmpSizeMB)
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, options); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	if spec.WorkDir == "/tmp" || strings.HasPrefix(spec.WorkDir, "/tmp/") {
+		if err := os.MkdirAll(spec.WorkDir, 0755); err != nil {
+			return err
+		}
+		source := fmt.Sprintf("/proc/self/fd/%d", workFD)
+		if err := unix.Mount(source, spec.WorkDir, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind work dir: %w", err)
+		}
+	}
+
+	// Свежий /proc для нового PID namespace. В контейнерах это может быть запрещено,
+	// тогда остается старый - процессы сервера там видны, но недоступны другому UID
+	unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	return nil
+}
+
+func setRlimits(limits Limits) error {
+	const mb = 1024 * 1024
+
+	type rlimit struct {
+		resource int
+		cur, max uint64
+	}
+	rlimits := []rlimit{{unix.RLIMIT_CORE, 0, 0}}
+	if limits.CPUSeconds > 0 {
+		// На мягком лимите приходит SIGXCPU (по нему узнаем причину), на жестком - SIGKILL
+		cpu := uint64(limits.CPUSeconds)
+		rlimits = append(rlimits, rlimit{unix.RLIMIT_CPU, cpu, cpu + 1})
+	}
+	if limits.AddressMB > 0 {
+		as := uint64(limits.AddressMB) * mb
+		rlimits = append(rlimits, rlimit{unix.RLIMIT_AS, as, as})
+	}
+	if limits.FileSizeMB > 0 {
+		fsize := uint64(limits.FileSizeMB) * mb
+		rlimits = append(rlimits, rlimit{unix.RLIMIT_FSIZE, fsize, fsize})
+	}
+	if limits.MaxProcesses > 0 {
+		nproc := uint64(limits.MaxProcesses)
+		rlimits = append(rlimits, rlimit{unix.RLIMIT_NPROC, nproc, nproc})
+	}
+
+	for _, l := range rlimits {
+		if err := unix.Setrlimit(l.resource, &unix.Rlimit{Cur: l.cur, Max: l.max}); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", l.resource, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"os"
+)
+
+// Sandbox на других платформах недоступна
+type Sandbox struct{}
+
+func New(cfg Config) (*Sandbox, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Sandbox) DefaultLimits() Limits {
+	return Limits{}
+}
+
+func (s *Sandbox) Command(ctx context.Context, workDir string, limits Limits, env []string, name string, args ...string) (*Run, error) {
+	return nil, ErrUnsupported
+}
+
+func (r *Run) Stats() Stats {
+	return Stats{}
+}
+
+func (r *Run) Close() {}
+
+func CPULimitExceeded(state *os.ProcessState, limits Limits) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const auditArch = unix.AUDIT_ARCH_X86_64
+
+// Вызовы x32 ABI имеют свои номера, их запрещаем целиком
+const x32SyscallBit = 0x40000000
+
+var archDeniedSyscalls = []uintptr{
+	unix.SYS_IOPL,
+	unix.SYS_IOPERM,
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const auditArch = unix.AUDIT_ARCH_AARCH64
+
+const x32SyscallBit = 0
+
+var archDeniedSyscalls []uintptr
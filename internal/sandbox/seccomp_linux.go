@@ -0,0 +1,141 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Seccomp-фильтр: системные вызовы для администрирования ядра, отладки
+// других процессов и выхода из namespace'ов возвращают EPERM.
+// clone с флагами новых namespace'ов тоже EPERM, а clone3 - ENOSYS:
+// его флаги лежат в памяти и BPF их не видит, libc тогда откатывается на clone
+
+const seccompSupported = true
+
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// смещения полей struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16 // младшие 32 бита args[0] на little-endian
+)
+
+// cloneNamespaceFlags - флаги clone, создающие новые namespace'ы
+const cloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_REBOOT,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_NAME_TO_HANDLE_AT,
+}
+
+// buildSeccompFilter собирает BPF-программу: чужая архитектура - kill,
+// запрещенный вызов или clone с namespace-флагами - EPERM, clone3 - ENOSYS,
+// остальное разрешено
+func buildSeccompFilter() []unix.SockFilter {
+	denied := append(append([]uintptr{}, deniedSyscalls...), archDeniedSyscalls...)
+
+	filter := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: seccompDataArch},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, Jf: 0, K: auditArch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetKillProcess},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: seccompDataNr},
+	}
+
+	// После проверок номеров идут: clone3, clone, загрузка флагов clone,
+	// проверка флагов, затем allow, EPERM и ENOSYS. BPF прыгает только вперед
+	allowIndex := len(filter) + len(denied) + 4
+	if x32SyscallBit != 0 {
+		allowIndex++
+	}
+	errnoIndex := allowIndex + 1
+	enosysIndex := allowIndex + 2
+	jump := func(to int) uint8 { return uint8(to - len(filter) - 1) }
+
+	if x32SyscallBit != 0 {
+		filter = append(filter, unix.SockFilter{
+			Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K,
+			Jt:   jump(errnoIndex),
+			K:    x32SyscallBit,
+		})
+	}
+
+	for _, nr := range denied {
+		filter = append(filter, unix.SockFilter{
+			Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K,
+			Jt:   jump(errnoIndex),
+			K:    uint32(nr),
+		})
+	}
+
+	filter = append(filter, unix.SockFilter{
+		Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K,
+		Jt:   jump(enosysIndex),
+		K:    unix.SYS_CLONE3,
+	})
+	filter = append(filter, unix.SockFilter{
+		Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K,
+		Jf:   jump(allowIndex),
+		K:    unix.SYS_CLONE,
+	})
+	filter = append(filter, unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: seccompDataArg0})
+	filter = append(filter, unix.SockFilter{
+		Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K,
+		Jt:   jump(errnoIndex),
+		Jf:   jump(allowIndex),
+		K:    cloneNamespaceFlags,
+	})
+
+	filter = append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetAllow},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetErrno | uint32(unix.EPERM)},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetErrno | uint32(unix.ENOSYS)},
+	)
+	return filter
+}
+
+// installSeccomp включает фильтр для текущего потока и всех, кто от него запустится
+func installSeccomp() error {
+	filter := buildSeccompFilter()
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+	return unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0)
+}
@@ -0,0 +1,11 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+import "errors"
+
+const seccompSupported = false
+
+func installSeccomp() error {
+	return errors.New("seccomp filter is not implemented for this architecture")
+}
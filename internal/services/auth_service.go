@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"strconv"
+	"sync"
 	"time"
 
 	"backend/internal/models"
@@ -12,6 +14,7 @@ import (
 // AuthService сервис для аутентификации
 type AuthService struct {
 	// Временное хранилище в памяти (позже заменим на БД)
+	mu     sync.RWMutex
 	users  map[string]*models.User
 	tokens map[string]string // token -> userID
 }
@@ -35,18 +38,23 @@ func (s *AuthService) GuestLogin() (*models.User, string, error) {
 		UpdatedAt: time.Now(),
 	}
 
-	// Сохраняем пользователя
-	s.users[user.ID] = user
-
 	// Создаем токен
 	token := generateRandomID(32)
+
+	// Сохраняем пользователя
+	s.mu.Lock()
+	s.users[user.ID] = user
 	s.tokens[token] = user.ID
+	s.mu.Unlock()
 
 	return user, token, nil
 }
 
 // ValidateToken проверяет валидность токена
 func (s *AuthService) ValidateToken(token string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	userID, exists := s.tokens[token]
 	if !exists {
 		return nil, errors.New("invalid token")
@@ -75,7 +83,7 @@ func generateRandomID(length int) string {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
 		// Fallback на timestamp если crypto недоступен
-		return string(time.Now().UnixNano())
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
 	}
 	return hex.EncodeToString(bytes)
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// Каталог задания boxDir - tmpfs того же размера, что и /tmp, чтобы
+// решение не могло забить диск хоста. Обычный tmpfs контейнера не
+// подходит: Docker копирует файлы в созданный, но не запущенный контейнер
+// только в тома, а артефакты сборки и отчет тестов читаются из уже
+// остановленного. Поэтому boxDir - том local с типом tmpfs. Такой том
+// теряет содержимое, как только его никто не держит смонтированным, и на
+// все время запуска его держит спящий контейнер-держатель
+
+// holderMemoryMB - память держателя, он только спит
+const holderMemoryMB = 16
+
+// box - том tmpfs и контейнер, который держит его смонтированным
+type box struct {
+	volume string
+	holder string
+}
+
+// createBox создает пустой том для boxDir и запускает держателя в образе
+// языка: sleep там точно есть. Удалять - removeBox
+func (s *DockerService) createBox(ctx context.Context, image string) (*box, error) {
+	vol, err := s.client.VolumeCreate(ctx, volume.CreateOptions{
+		Driver: "local",
+		DriverOpts: map[string]string{
+			"type":   "tmpfs",
+			"device": "tmpfs",
+			"o":      fmt.Sprintf("size=%dm,mode=0755,nosuid,nodev", s.tmpSizeMB),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create box volume: %w", err)
+	}
+	b := &box{volume: vol.Name}
+
+	config := &container.Config{
+		Image: image,
+		// Держателя удаляем сами, сутки - только верхняя граница
+		Cmd:        []string{"sleep", "86400"},
+		WorkingDir: "/",
+	}
+	host := &container.HostConfig{
+		Resources: container.Resources{
+			Memory: holderMemoryMB * 1024 * 1024,
+		},
+		NetworkMode:    "none",
+		ReadonlyRootfs: true,
+		Mounts:         []mount.Mount{b.mount()},
+	}
+	s.applySecurity(config, host)
+
+	resp, err := s.client.ContainerCreate(ctx, config, host, nil, nil, "")
+	if err != nil {
+		s.removeBox(b)
+		return nil, fmt.Errorf("failed to create box holder: %w", err)
+	}
+	b.holder = resp.ID
+	if err := s.startContainer(ctx, b.holder); err != nil {
+		s.removeBox(b)
+		return nil, fmt.Errorf("failed to start box holder: %w", err)
+	}
+	return b, nil
+}
+
+// mount - том в контейнере задания
+func (b *box) mount() mount.Mount {
+	return mount.Mount{
+		Type:   mount.TypeVolume,
+		Source: b.volume,
+		Target: boxDir,
+	}
+}
+
+// removeBox удаляет держателя и том. Контейнер задания к этому времени
+// уже должен быть удален, иначе том занят
+func (s *DockerService) removeBox(b *box) {
+	if b.holder != "" {
+		s.removeContainer(b.holder)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.client.VolumeRemove(ctx, b.volume, true); err != nil {
+		log.Printf("Warning: failed to remove volume %s: %v", b.volume, err)
+	}
+}
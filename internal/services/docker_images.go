@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"backend/internal/utils"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Управление судейскими образами: сборка из images/, скачивание,
+// проверка при старте и digest образа для результатов запусков
+
+// ErrImageUnavailable - образа языка нет на хосте. Это не сбой Docker:
+// остальные языки в нем по-прежнему запускаются
+var ErrImageUnavailable = errors.New("image is not available")
+
+// ImageDigest - неизменяемая ссылка на образ: repo@sha256:... из реестра,
+// а для собранного локально и не опубликованного - ID образа
+func (s *DockerService) ImageDigest(ctx context.Context, image string) (string, error) {
+	inspect, _, err := s.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	if len(inspect.RepoDigests) > 0 {
+		return inspect.RepoDigests[0], nil
+	}
+	return inspect.ID, nil
+}
+
+// PullImage скачивает образ, прогресс пишет в out
+func (s *DockerService) PullImage(ctx context.Context, image string, out io.Writer) error {
+	body, err := s.client.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	defer body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(body, out, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	return nil
+}
+
+// BuildImage собирает образ tag из каталога contextDir с Dockerfile.
+// Базовый образ каждый раз перепроверяется в реестре
+func (s *DockerService) BuildImage(ctx context.Context, contextDir, tag string, out io.Writer) error {
+	buildContext, err := utils.CreateTarArchiveDir(contextDir)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", contextDir, err)
+	}
+
+	resp, err := s.client.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        []string{tag},
+		Dockerfile:  "Dockerfile",
+		PullParent:  true,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build %s: %w", tag, err)
+	}
+	defer resp.Body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, out, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to build %s: %w", tag, err)
+	}
+	return nil
+}
+
+// EnsureImages проверяет, что образы есть локально. Недостающие
+// скачиваются, если pull. Возвращает образы, которых так и нет
+func (s *DockerService) EnsureImages(ctx context.Context, images []string, pull bool) []string {
+	var missing []string
+	for _, image := range images {
+		exists, err := s.ImageExists(ctx, image)
+		if err != nil {
+			log.Printf("⚠️ Failed to check image %s: %v", image, err)
+		}
+		if exists {
+			continue
+		}
+		if pull {
+			log.Printf("⬇️ Pulling missing image %s", image)
+			err := s.PullImage(ctx, image, io.Discard)
+			if err == nil {
+				continue
+			}
+			log.Printf("❌ %v", err)
+		}
+		missing = append(missing, image)
+	}
+	if len(missing) > 0 {
+		log.Printf("⚠️ Missing Docker images: %s (build them with cmd/judge-images)", strings.Join(missing, ", "))
+	}
+	return missing
+}
+
+// RunImage запускает команду в образе с теми же ограничениями, что и
+// решения, и возвращает ее вывод. Нужна для проверки собранных образов
+func (s *DockerService) RunImage(ctx context.Context, image string, cmd []string) (string, error) {
+	config := &container.Config{
+		Image:      image,
+		Cmd:        cmd,
+		WorkingDir: "/tmp",
+		Env:        []string{"HOME=/tmp"},
+	}
+	host := &container.HostConfig{
+		Resources: container.Resources{
+			Memory: int64(dockerMemoryMB) * 4 * 1024 * 1024,
+		},
+		NetworkMode:    "none",
+		ReadonlyRootfs: true,
+		Tmpfs: map[string]string{
+			"/tmp": fmt.Sprintf("rw,nosuid,nodev,exec,size=%dm", s.tmpSizeMB),
+		},
+	}
+	s.applySecurity(config, host)
+
+	resp, err := s.client.ContainerCreate(ctx, config, host, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer s.removeContainer(resp.ID)
+
+	if err := s.startContainer(ctx, resp.ID); err != nil {
+		return "", err
+	}
+	result, _, err := s.waitForCompletion(ctx, resp.ID)
+	if err != nil {
+		return "", err
+	}
+
+	logs, err := s.client.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return "", err
+	}
+	if !result.Success {
+		return output.String(), fmt.Errorf("%s: %s", strings.Join(cmd, " "), result.Error)
+	}
+	return output.String(), nil
+}
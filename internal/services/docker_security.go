@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	units "github.com/docker/go-units"
+)
+
+// DockerSecurity профиль безопасности контейнеров. Нулевое значение -
+// настройки Docker по умолчанию
+type DockerSecurity struct {
+	User            string // "uid" или "uid:gid", пусто - пользователь образа
+	CapDropAll      bool   // сбросить все capabilities
+	NoNewPrivileges bool   // запретить повышение прав через setuid
+	PidsLimit       int64  // процессов и потоков в контейнере
+	NoFile          int64  // RLIMIT_NOFILE
+	FileSizeMB      int64  // RLIMIT_FSIZE
+	// SeccompProfile - путь к JSON профилю seccomp или unconfined.
+	// Пусто - профиль Docker по умолчанию
+	SeccompProfile string
+	DisableSwap    bool // memory-swap = memory
+}
+
+// SetSecurity задает профиль безопасности контейнеров
+func (s *DockerService) SetSecurity(security DockerSecurity) error {
+	uid, gid, err := parseUser(security.User)
+	if err != nil {
+		return err
+	}
+
+	var securityOpt []string
+	if security.NoNewPrivileges {
+		securityOpt = append(securityOpt, "no-new-privileges:true")
+	}
+	switch security.SeccompProfile {
+	case "":
+	case "unconfined":
+		securityOpt = append(securityOpt, "seccomp=unconfined")
+	default:
+		// Docker API принимает сам профиль, а не путь к нему
+		profile, err := os.ReadFile(security.SeccompProfile)
+		if err != nil {
+			return fmt.Errorf("failed to read seccomp profile: %w", err)
+		}
+		securityOpt = append(securityOpt, "seccomp="+string(profile))
+	}
+
+	s.security = security
+	s.securityOpt = securityOpt
+	s.uid, s.gid = uid, gid
+	return nil
+}
+
+// parseUser разбирает "uid[:gid]". Имена не поддерживаются: по UID
+// выставляются владельцы файлов, копируемых в контейнер
+func parseUser(user string) (int, int, error) {
+	if user == "" {
+		return 0, 0, nil
+	}
+	uidPart, gidPart, hasGID := strings.Cut(user, ":")
+	uid, err := strconv.Atoi(uidPart)
+	if err != nil || uid < 0 {
+		return 0, 0, fmt.Errorf("invalid container user %q: expected uid[:gid]", user)
+	}
+	gid := uid
+	if hasGID {
+		if gid, err = strconv.Atoi(gidPart); err != nil || gid < 0 {
+			return 0, 0, fmt.Errorf("invalid container user %q: expected uid[:gid]", user)
+		}
+	}
+	return uid, gid, nil
+}
+
+// applySecurity добавляет профиль безопасности к настройкам контейнера
+func (s *DockerService) applySecurity(config *container.Config, host *container.HostConfig) {
+	security := s.security
+	config.User = security.User
+	if security.CapDropAll {
+		host.CapDrop = []string{"ALL"}
+	}
+	host.SecurityOpt = s.securityOpt
+	if security.PidsLimit > 0 {
+		pids := security.PidsLimit
+		host.Resources.PidsLimit = &pids
+	}
+	if security.NoFile > 0 {
+		host.Resources.Ulimits = append(host.Resources.Ulimits, &units.Ulimit{
+			Name: "nofile", Soft: security.NoFile, Hard: security.NoFile,
+		})
+	}
+	if security.FileSizeMB > 0 {
+		size := security.FileSizeMB * 1024 * 1024
+		host.Resources.Ulimits = append(host.Resources.Ulimits, &units.Ulimit{
+			Name: "fsize", Soft: size, Hard: size,
+		})
+	}
+	if security.DisableSwap {
+		host.Resources.MemorySwap = host.Resources.Memory
+	}
+}
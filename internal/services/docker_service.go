@@ -1,21 +1,28 @@
 package services
 
 import (
-	"bufio"
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 
+	"backend/internal/compilecache"
+	"backend/internal/executor"
+	"backend/internal/languages"
 	"backend/internal/models"
+	"backend/internal/utils"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 // Docker файл сервиса. Изолятор выполнения кода
@@ -23,10 +30,30 @@ import (
 // Обертка над Docker API через официальный Go клиент
 // Единственное поле - клиент для взаимодействия с Docker демоном
 type DockerService struct {
-	client *client.Client
+	client      *client.Client
+	languages   *languages.Registry
+	outputLimit int64 // сколько байт stdout и stderr разрешено программе
+	tmpSizeMB   int   // размер tmpfs /tmp и тома /box
+	// compileCache - кэш собранных программ, nil - сборка каждый раз
+	compileCache *compilecache.Cache
+
+	security    DockerSecurity
+	securityOpt []string
+	uid, gid    int // владелец файлов решения в контейнере
 }
 
-func NewDockerService() (*DockerService, error) {
+// dockerMemoryMB - память контейнера, если язык не задал свою
+const dockerMemoryMB = 100
+
+// dockerSetupTimeout - сколько ждем подготовку контейнера: том, создание,
+// копирование кода и старт. В лимит времени задачи она не входит
+const dockerSetupTimeout = 30 * time.Second
+
+// dockerTmpSizeMB - размер /tmp в контейнере по умолчанию. Кэш сборки Go
+// для программы с fmt занимает около 35 МБ
+const dockerTmpSizeMB = 128
+
+func NewDockerService(registry *languages.Registry) (*DockerService, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		log.Printf("⚠️ Docker client creation failed: %v", err)
@@ -44,131 +71,407 @@ func NewDockerService() (*DockerService, error) {
 	}
 
 	log.Println("✅ Docker service initialized successfully")
-	return &DockerService{client: cli}, nil
-}
-
-// LanguageConfigs конфигурация для разных языков программирования
-var LanguageConfigs = map[string]models.LanguageConfig{
-	"python": {
-		DockerImage: "python:3.9-alpine",
-		RunCmd:      []string{"python", "/app/code.py"},
-		FileName:    "code.py",
-		Timeout:     10 * time.Second,
-	},
-	"javascript": {
-		DockerImage: "node:18-alpine",
-		RunCmd:      []string{"node", "/app/code.js"},
-		FileName:    "code.js",
-		Timeout:     10 * time.Second,
-	},
-	"java": {
-		DockerImage: "openjdk:17-alpine",
-		CompileCmd:  []string{"javac", "/app/Main.java"}, // Компилируем Main.java
-		RunCmd:      []string{"java", "Main"},            // Запускаем класс Main
-		FileName:    "Main.java",                         // Файл должен называться Main.java
-		Timeout:     15 * time.Second,
-	},
-	"cpp": {
-		DockerImage: "gcc:latest",
-		CompileCmd:  []string{"g++", "-o", "/app/code", "/app/code.cpp"},
-		RunCmd:      []string{"/app/code"},
-		FileName:    "code.cpp",
-		Timeout:     15 * time.Second,
-	},
-	"go": {
-		DockerImage: "golang:1.19-alpine",
-		RunCmd:      []string{"go", "run", "/app/code.go"},
-		FileName:    "code.go",
-		Timeout:     10 * time.Second,
-	},
-}
-
-func (s *DockerService) ExecuteCode(code, language string) (*models.ExecutionResult, error) {
-	config, exists := LanguageConfigs[language]
-	if !exists {
-		return nil, fmt.Errorf("unsupported language: %s", language)
-	}
-
-	log.Printf("🔄 Executing %s code: %s", language, code)
-
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
-	defer cancel()
+	return &DockerService{
+		client:      cli,
+		languages:   registry,
+		outputLimit: executor.DefaultOutputLimit,
+		tmpSizeMB:   dockerTmpSizeMB,
+	}, nil
+}
+
+// SetOutputLimit задает лимит вывода программы в байтах
+func (s *DockerService) SetOutputLimit(limit int64) {
+	if limit > 0 {
+		s.outputLimit = limit
+	}
+}
+
+// SetTmpSize задает размер tmpfs /tmp и тома /box в контейнере
+func (s *DockerService) SetTmpSize(sizeMB int) {
+	if sizeMB > 0 {
+		s.tmpSizeMB = sizeMB
+	}
+}
 
-	// Создаем временный файл с кодом
-	tempDir, err := os.MkdirTemp("", "code-execution")
+// SetCompileCache включает кэш собранных программ
+func (s *DockerService) SetCompileCache(cache *compilecache.Cache) {
+	s.compileCache = cache
+}
+
+// ExecuteCode выполняет код и возвращает весь вывод разом.
+// Отмена ctx сразу удаляет контейнер
+func (s *DockerService) ExecuteCode(ctx context.Context, job executor.Job) (*models.ExecutionResult, error) {
+	// stdout и stderr пишем в один буфер, как их отдавал docker logs
+	var output bytes.Buffer
+	result, err := s.ExecuteStream(ctx, job, executor.Streams{Stdin: job.StdinReader(), Stdout: &output, Stderr: &output})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Записываем код в файл
-	filePath := filepath.Join(tempDir, config.FileName)
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write code to file: %w", err)
+	result.Output = strings.TrimSpace(output.String())
+
+	log.Printf("✅ Execution result: success=%v, output=%s", result.Success, result.Output)
+
+	return result, nil
+}
+
+// ExecuteStream выполняет код в контейнере, передавая stdout и stderr
+// по мере их появления (docker logs с Follow: true). Если задан streams.Stdin,
+// контейнер подключается через attach, и ввод пересылается программе.
+// Отмена ctx или превышение лимита вывода останавливает и удаляет контейнер
+// вместе со всеми процессами в нем
+func (s *DockerService) ExecuteStream(ctx context.Context, job executor.Job, streams executor.Streams) (*models.ExecutionResult, error) {
+	lang, exists := s.languages.Lookup(job.Language)
+	if exists {
+		lang, exists = job.ResolveLanguage(lang)
+	}
+	if !exists || lang.Docker.Image == "" {
+		return nil, fmt.Errorf("unsupported language: %s", job.Language)
 	}
 
-	log.Printf("📁 Code written to: %s", filePath)
+	log.Printf("🔄 Executing %s code: %s", lang.ID, job.Code)
+
+	// Digest запоминаем до запуска: тег потом могут перенести на другой образ
+	digest, err := s.ImageDigest(ctx, lang.Docker.Image)
+	if client.IsErrNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", ErrImageUnavailable, lang.Docker.Image)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", lang.Docker.Image, err)
+	}
+
+	// Дерево задания копируется в контейнер архивом, каталоги хоста не монтируются
+	tree, err := job.Tree(lang)
+	if err != nil {
+		return nil, err
+	}
+	files := tree
+	if lang.Compiled() {
+		artifacts, failed, err := s.build(ctx, lang, job, tree, digest)
+		if err != nil || failed != nil {
+			return failed, err
+		}
+		files = append(files, artifacts...)
+	}
+
+	setupCtx, cancelSetup := context.WithTimeout(ctx, dockerSetupTimeout)
+	defer cancelSetup()
+
+	files = append(files, job.RunTree()...)
+	archive, err := utils.CreateTarArchiveFiles(s.boxFiles(files))
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive code: %w", err)
+	}
 
 	// Создаем контейнер
-	containerID, err := s.createContainer(ctx, tempDir, config)
+	b, err := s.createBox(setupCtx, lang.Docker.Image)
+	if err != nil {
+		return nil, err
+	}
+	defer s.removeBox(b)
+
+	interactive := streams.Stdin != nil
+	vars := lang.VarsFor(appDir, "").WithSources(job.Sources(lang))
+	containerID, err := s.createContainer(setupCtx, lang, b, vars.Expand(lang.DockerRun()), vars.Expand(lang.RunEnv), job.MemoryLimitMB(lang), interactive)
 	if err != nil {
 		log.Printf("❌ Failed to create container: %v", err)
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
-	defer s.removeContainer(ctx, containerID)
+	defer s.removeContainer(containerID)
 
 	log.Printf("🐳 Container created: %s", containerID)
 
+	if err := s.client.CopyToContainer(setupCtx, containerID, boxDir, archive, types.CopyToContainerOptions{}); err != nil {
+		log.Printf("❌ Failed to copy code to container: %v", err)
+		return nil, fmt.Errorf("failed to copy code to container: %w", err)
+	}
+
+	log.Printf("📁 Code copied to %s:%s (%d files)", containerID[:12], appDir, len(files))
+
+	// Для интерактивной сессии подключаемся до старта, чтобы не потерять ввод
+	// и вывод. Поток живет весь запуск, поэтому без таймаута подготовки
+	var attached *types.HijackedResponse
+	if interactive {
+		resp, err := s.attachContainer(ctx, containerID, streams.Stdin)
+		if err != nil {
+			log.Printf("❌ Failed to attach to container: %v", err)
+			return nil, fmt.Errorf("failed to attach to container: %w", err)
+		}
+		defer resp.Close()
+		attached = &resp
+	}
+
 	// Запускаем контейнер
-	if err := s.startContainer(ctx, containerID); err != nil {
+	if err := s.startContainer(setupCtx, containerID); err != nil {
 		log.Printf("❌ Failed to start container: %v", err)
 		return nil, fmt.Errorf("failed to start container: %w", err)
 	}
 
 	log.Printf("🚀 Container started: %s", containerID)
 
-	// Ждем завершения и получаем результат
-	result, err := s.waitForCompletion(ctx, containerID, config)
+	// Сборка ограничена своим таймаутом, запуску - только лимит времени
+	// задачи, и отсчитывается он от старта контейнера
+	started := time.Now()
+	ctx, cancel := executor.WithDefaultTimeout(ctx, job.TimeLimit(lang))
+	defer cancel()
+
+	// Замеры собираем параллельно, после выхода контейнера cgroup уже не прочитать
+	usage := s.watchStats(ctx, containerID)
+
+	// Читаем вывод, пока контейнер не завершится
+	outputLimit := job.OutputLimit(s.outputLimit)
+	limiter := executor.NewOutputLimiter(outputLimit, cancel)
+	stdout := limiter.Wrap(streams.Stdout)
+	stderr := limiter.Wrap(streams.Stderr)
+
+	var copyErr error
+	if attached != nil {
+		copyErr = copyOutput(ctx, attached.Reader, stdout, stderr)
+	} else {
+		copyErr = s.followContainerLogs(ctx, containerID, stdout, stderr)
+	}
+	if limiter.Exceeded() {
+		return usage.apply(&models.ExecutionResult{
+			Success:     false,
+			Error:       fmt.Sprintf("Output limit exceeded (%d bytes)", outputLimit),
+			Verdict:     models.VerdictOutputLimit,
+			ImageDigest: digest,
+		}, time.Since(started)), nil
+	}
+	if ctx.Err() != nil {
+		verdict := models.VerdictTimeLimit
+		if ctx.Err() == context.Canceled {
+			verdict = models.VerdictCancelled
+		}
+		return usage.apply(&models.ExecutionResult{
+			Success:     false,
+			Error:       executor.TimeoutMessage(ctx, started),
+			Verdict:     verdict,
+			ImageDigest: digest,
+		}, time.Since(started)), nil
+	}
+	if copyErr != nil {
+		log.Printf("❌ Failed to read container output: %v", copyErr)
+		return nil, fmt.Errorf("failed to read container output: %w", copyErr)
+	}
+
+	// Получаем результат
+	result, wallTime, err := s.waitForCompletion(ctx, containerID)
 	if err != nil {
 		log.Printf("❌ Failed to wait for completion: %v", err)
 		return nil, fmt.Errorf("failed to wait for completion: %w", err)
 	}
+	if wallTime == 0 {
+		wallTime = time.Since(started)
+	}
+	result.ImageDigest = digest
+	if job.Unit && lang.Unit.Report != "" {
+		result.Report = s.readContainerFile(ctx, containerID, path.Join(appDir, lang.Unit.Report), outputLimit)
+	}
 
-	log.Printf("✅ Execution result: success=%v, output=%s", result.Success, result.Output)
+	return usage.apply(result, wallTime), nil
+}
 
-	return result, nil
+// build собирает решение в отдельном контейнере и возвращает файлы,
+// которые сборка добавила к дереву задания, или берет их из кэша.
+// Если сборка не удалась, возвращает результат с ошибкой компиляции
+func (s *DockerService) build(ctx context.Context, lang *languages.Language, job executor.Job, tree []utils.TarFile, digest string) ([]utils.TarFile, *models.ExecutionResult, error) {
+	// Digest образа и есть версия компилятора
+	compile := lang.DockerCompile()
+	key := executor.CompileKey(lang, digest, compile, tree)
+	if s.compileCache != nil {
+		if artifacts, ok := s.compileCache.Get(key); ok {
+			log.Printf("♻️ Compile cache hit for %s (%s)", lang.ID, key[:12])
+			return artifacts, nil, nil
+		}
+	}
+
+	setupCtx, cancelSetup := context.WithTimeout(ctx, dockerSetupTimeout)
+	defer cancelSetup()
+
+	archive, err := utils.CreateTarArchiveFiles(s.boxFiles(tree))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to archive code: %w", err)
+	}
+	b, err := s.createBox(setupCtx, lang.Docker.Image)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.removeBox(b)
+
+	vars := lang.VarsFor(appDir, "").WithSources(job.Sources(lang))
+	containerID, err := s.createContainer(setupCtx, lang, b, vars.Expand(compile), vars.Expand(lang.CompileEnv), executor.CompileMemoryMB, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create build container: %w", err)
+	}
+	defer s.removeContainer(containerID)
+
+	if err := s.client.CopyToContainer(setupCtx, containerID, boxDir, archive, types.CopyToContainerOptions{}); err != nil {
+		return nil, nil, fmt.Errorf("failed to copy code to build container: %w", err)
+	}
+
+	log.Printf("🔨 Building %s in %s", lang.ID, containerID[:12])
+
+	if err := s.startContainer(setupCtx, containerID); err != nil {
+		return nil, nil, fmt.Errorf("failed to start build container: %w", err)
+	}
+
+	// Таймаут сборки, как и лимит запуска, отсчитывается от старта
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, lang.CompileTimeout())
+	defer cancel()
+
+	// Сообщения компилятора - текст ошибки компиляции
+	var output bytes.Buffer
+	limiter := executor.NewOutputLimiter(s.outputLimit, cancel)
+	copyErr := s.followContainerLogs(ctx, containerID, limiter.Wrap(&output), limiter.Wrap(&output))
+	switch {
+	case limiter.Exceeded():
+		return nil, compileFailed(models.VerdictCompilationError, "Compilation failed: "+output.String(), digest), nil
+	case ctx.Err() == context.Canceled:
+		return nil, compileFailed(models.VerdictCancelled, executor.TimeoutMessage(ctx, started), digest), nil
+	case ctx.Err() != nil:
+		return nil, compileFailed(models.VerdictCompilationError, "Compilation failed: "+executor.TimeoutMessage(ctx, started), digest), nil
+	case copyErr != nil:
+		return nil, nil, fmt.Errorf("failed to read build output: %w", copyErr)
+	}
+
+	result, _, err := s.waitForCompletion(ctx, containerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wait for build: %w", err)
+	}
+	if !result.Success {
+		message := output.String()
+		if result.Verdict == models.VerdictMemoryLimit {
+			message = result.Error
+		}
+		return nil, compileFailed(models.VerdictCompilationError, "Compilation failed: "+message, digest), nil
+	}
+
+	artifacts, err := s.readArtifacts(ctx, containerID, tree)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read build artifacts: %w", err)
+	}
+	if s.compileCache != nil {
+		// Кэш - только ускорение: если сборку не сохранить, запуск все равно идет
+		if err := s.compileCache.Put(key, artifacts); err != nil {
+			log.Printf("⚠️ Build of %s is not cached: %v", lang.ID, err)
+		}
+	}
+	return artifacts, nil, nil
+}
+
+func compileFailed(verdict, message, digest string) *models.ExecutionResult {
+	return &models.ExecutionResult{
+		Success:     false,
+		Error:       message,
+		Verdict:     verdict,
+		ImageDigest: digest,
+	}
+}
+
+// readArtifacts забирает из остановленного контейнера файлы appDir,
+// которых нет в дереве задания
+func (s *DockerService) readArtifacts(ctx context.Context, containerID string, tree []utils.TarFile) ([]utils.TarFile, error) {
+	known := make(map[string]bool, len(tree))
+	for _, file := range tree {
+		known[file.Name] = true
+	}
+
+	reader, _, err := s.client.CopyFromContainer(ctx, containerID, appDir)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// Записи архива начинаются с имени каталога: app/main
+	var artifacts []utils.TarFile
+	var size int64
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return artifacts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, name, _ := strings.Cut(header.Name, "/")
+		if header.Typeflag != tar.TypeReg || known[name] {
+			continue
+		}
+		if size += header.Size; size > executor.MaxArtifactsSize {
+			return nil, fmt.Errorf("build artifacts are larger than %d bytes", executor.MaxArtifactsSize)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, utils.TarFile{Name: name, Content: string(content), Mode: header.Mode & 0777})
+	}
 }
 
-func (s *DockerService) createContainer(ctx context.Context, codePath string, config models.LanguageConfig) (string, error) {
-	// Подготавливаем команды
-	cmd := config.RunCmd
-	if len(config.CompileCmd) > 0 {
-		// Если нужна компиляция, объединяем команды
-		compileCmd := strings.Join(config.CompileCmd, " ")
-		runCmd := strings.Join(config.RunCmd, " ")
-		cmd = []string{"/bin/sh", "-c", fmt.Sprintf("%s && %s", compileCmd, runCmd)}
+// Каталоги в контейнере: boxDir - том tmpfs на время запуска (см. box),
+// appDir - рабочий каталог в нем с деревом задания
+const (
+	boxDir = "/box"
+	appDir = boxDir + "/app"
+)
+
+// boxFiles - дерево задания внутри boxDir. Каталог решения и файлы решения
+// принадлежат пользователю контейнера (сборка пишет рядом с кодом), файлы
+// задачи только для чтения остаются у root. Владельца корня тома через
+// CopyToContainer не сменить, поэтому код лежит в подкаталоге
+func (s *DockerService) boxFiles(files []utils.TarFile) []utils.TarFile {
+	boxed := []utils.TarFile{{Name: "app/", UID: s.uid, GID: s.gid}}
+	for _, file := range files {
+		file.Name = "app/" + file.Name
+		if file.Mode&0222 != 0 {
+			file.UID, file.GID = s.uid, s.gid
+		}
+		boxed = append(boxed, file)
 	}
+	return boxed
+}
 
-	resp, err := s.client.ContainerCreate(ctx, &container.Config{
-		Image:      config.DockerImage,
+// createContainer создает контейнер для команды cmd (сборки или запуска)
+// с корнем только для чтения. Код лежит в томе b, сборка пишет результаты
+// рядом с ним. Остальное (HOME, кэши компиляторов) - в небольшом tmpfs
+// /tmp. env - окружение сборки или запуска
+func (s *DockerService) createContainer(ctx context.Context, lang *languages.Language, b *box, cmd, env []string, memoryMB int, interactive bool) (string, error) {
+	if memoryMB == 0 {
+		memoryMB = dockerMemoryMB
+	}
+
+	config := &container.Config{
+		Image:      lang.Docker.Image,
 		Cmd:        cmd,
 		Tty:        false,
-		WorkingDir: "/app",
-	}, &container.HostConfig{
+		WorkingDir: appDir,
+		Env:        append([]string{"HOME=/tmp"}, env...),
+		// Stdin открыт только когда есть ввод: сессия или ввод теста
+		OpenStdin:    interactive,
+		StdinOnce:    interactive,
+		AttachStdin:  interactive,
+		AttachStdout: interactive,
+		AttachStderr: interactive,
+	}
+	host := &container.HostConfig{
 		Resources: container.Resources{
-			Memory:    100 * 1024 * 1024, // 100MB limit
-			CPUShares: 512,               // CPU limit
+			Memory:    int64(memoryMB) * 1024 * 1024,
+			CPUShares: 512, // CPU limit
 		},
-		AutoRemove:  false,
-		NetworkMode: "none", // Без сети для безопасности
-		Mounts: []mount.Mount{
-			{
-				Type:   mount.TypeBind,
-				Source: codePath,
-				Target: "/app",
-			},
+		AutoRemove:     false,
+		NetworkMode:    "none", // Без сети для безопасности
+		ReadonlyRootfs: true,
+		Mounts:         []mount.Mount{b.mount()},
+		Tmpfs: map[string]string{
+			"/tmp": fmt.Sprintf("rw,nosuid,nodev,exec,size=%dm", s.tmpSizeMB),
 		},
-	}, nil, nil, "")
+	}
+	s.applySecurity(config, host)
+
+	resp, err := s.client.ContainerCreate(ctx, config, host, nil, nil, "")
 	if err != nil {
 		return "", err
 	}
@@ -176,71 +479,189 @@ func (s *DockerService) createContainer(ctx context.Context, codePath string, co
 	return resp.ID, nil
 }
 
+// ImageExists проверяет, что образ уже есть локально (без скачивания)
+func (s *DockerService) ImageExists(ctx context.Context, image string) (bool, error) {
+	_, _, err := s.client.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return true, nil
+	}
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
 func (s *DockerService) startContainer(ctx context.Context, containerID string) error {
 	return s.client.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
 }
 
-func (s *DockerService) waitForCompletion(ctx context.Context, containerID string, config models.LanguageConfig) (*models.ExecutionResult, error) {
+// waitForCompletion ждет выхода контейнера. Кроме результата возвращает
+// время работы по меткам Docker (0, если их не удалось разобрать)
+func (s *DockerService) waitForCompletion(ctx context.Context, containerID string) (*models.ExecutionResult, time.Duration, error) {
 	statusCh, errCh := s.client.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
 
 	select {
 	case err := <-errCh:
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	case <-statusCh:
 	}
 
-	// Получаем логи
-	logs, err := s.getContainerLogs(ctx, containerID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Проверяем статус выполнения
 	inspect, err := s.client.ContainerInspect(ctx, containerID)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	var wallTime time.Duration
+	startedAt, startErr := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+	finishedAt, finishErr := time.Parse(time.RFC3339Nano, inspect.State.FinishedAt)
+	if startErr == nil && finishErr == nil && finishedAt.After(startedAt) {
+		wallTime = finishedAt.Sub(startedAt)
 	}
 
 	result := &models.ExecutionResult{
-		Output:  logs,
 		Success: inspect.State.ExitCode == 0,
+		Verdict: models.VerdictOK,
 	}
 
 	if inspect.State.ExitCode != 0 {
 		result.Error = fmt.Sprintf("Exit code: %d", inspect.State.ExitCode)
+		result.Verdict = models.VerdictRuntimeError
+	}
+	if inspect.State.OOMKilled {
+		result.Error = "Memory limit exceeded"
+		result.Verdict = models.VerdictMemoryLimit
 	}
 
-	return result, nil
+	return result, wallTime, nil
+}
+
+// containerUsage - замеры контейнера из потока docker stats
+type containerUsage struct {
+	done  chan struct{}
+	stats models.ExecutionStats
+}
+
+// watchStats читает docker stats, пока контейнер работает или не отменен ctx.
+// Docker присылает замер примерно раз в секунду, поэтому у очень коротких
+// запусков CPU и память могут остаться нулевыми
+func (s *DockerService) watchStats(ctx context.Context, containerID string) *containerUsage {
+	usage := &containerUsage{done: make(chan struct{})}
+
+	go func() {
+		defer close(usage.done)
+
+		resp, err := s.client.ContainerStats(ctx, containerID, true)
+		if err != nil {
+			log.Printf("⚠️ Failed to read stats of container %s: %v", containerID, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var sample types.StatsJSON
+			if err := decoder.Decode(&sample); err != nil {
+				return
+			}
+			cpu := sample.CPUStats.CPUUsage
+			if cpuTime := int64(cpu.UsageInUsermode+cpu.UsageInKernelmode) / int64(time.Millisecond); cpuTime > usage.stats.CPUTimeMs {
+				usage.stats.CPUTimeMs = cpuTime
+			}
+			// MaxUsage есть только в cgroup v1, в v2 берем максимум из замеров
+			memory := sample.MemoryStats.Usage
+			if sample.MemoryStats.MaxUsage > memory {
+				memory = sample.MemoryStats.MaxUsage
+			}
+			if kb := int64(memory / 1024); kb > usage.stats.PeakMemoryKB {
+				usage.stats.PeakMemoryKB = kb
+			}
+		}
+	}()
+
+	return usage
 }
 
-func (s *DockerService) getContainerLogs(ctx context.Context, containerID string) (string, error) {
+// apply дожидается замеров и добавляет их в result
+func (u *containerUsage) apply(result *models.ExecutionResult, wallTime time.Duration) *models.ExecutionResult {
+	select {
+	case <-u.done:
+	case <-time.After(2 * time.Second):
+		// Поток stats не закрылся вместе с контейнером, берем что успели прочитать
+		log.Printf("⚠️ Container stats stream did not finish in time")
+		return result
+	}
+
+	result.ExecutionTime = wallTime
+	result.Stats = u.stats
+	result.Stats.WallTimeMs = wallTime.Milliseconds()
+	return result
+}
+
+// followContainerLogs копирует вывод контейнера в stdout и stderr до его завершения.
+// Docker мультиплексирует потоки с 8-байтовыми заголовками, их разбирает stdcopy
+func (s *DockerService) followContainerLogs(ctx context.Context, containerID string, stdout, stderr io.Writer) error {
 	reader, err := s.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
-		Follow:     false,
+		Follow:     true,
 	})
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer reader.Close()
 
-	var output strings.Builder
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		// Docker добавляет префиксы к логам, пропускаем их
-		line := scanner.Text()
-		if len(line) > 8 {
-			output.WriteString(line[8:])
-			output.WriteString("\n")
-		}
+	_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	return err
+}
+
+// attachContainer подключается к stdin/stdout/stderr контейнера и пересылает в него stdin.
+// Когда ввод заканчивается, stdin контейнера закрывается
+func (s *DockerService) attachContainer(ctx context.Context, containerID string, stdin io.Reader) (types.HijackedResponse, error) {
+	resp, err := s.client.ContainerAttach(ctx, containerID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return resp, err
 	}
 
-	return strings.TrimSpace(output.String()), nil
+	go func() {
+		if _, err := io.Copy(resp.Conn, stdin); err != nil {
+			log.Printf("⚠️ Failed to forward stdin to container %s: %v", containerID, err)
+		}
+		resp.CloseWrite()
+	}()
+
+	return resp, nil
 }
 
-func (s *DockerService) removeContainer(ctx context.Context, containerID string) {
+// copyOutput разбирает мультиплексированный поток контейнера.
+// Чтение прерывается при отмене ctx
+func copyOutput(ctx context.Context, reader io.Reader, stdout, stderr io.Writer) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, reader)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *DockerService) removeContainer(containerID string) {
+	// Отдельный контекст: основной уже может быть отменен по таймауту
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	err := s.client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
 		Force: true,
 	})
@@ -248,3 +669,29 @@ func (s *DockerService) removeContainer(ctx context.Context, containerID string)
 		log.Printf("Warning: failed to remove container %s: %v", containerID, err)
 	}
 }
+
+// readContainerFile читает файл из контейнера (в том числе остановленного),
+// не больше limit байт. Пустая строка - файла нет
+func (s *DockerService) readContainerFile(ctx context.Context, containerID, name string, limit int64) string {
+	// Контекст запуска мог истечь по таймауту, а файл все равно нужен
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	reader, _, err := s.client.CopyFromContainer(ctx, containerID, name)
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	// Docker отдает файл tar-архивом из одной записи
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil || header.Typeflag != tar.TypeReg {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, limit))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,298 @@
+package unittest
+
+import (
+	"backend/internal/models"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Разбор отчетов тестовых фреймворков в результаты по тестам:
+//   junit  - JUnit XML (pytest --junitxml, JUnit Platform, GoogleTest)
+//   jest   - JSON отчет Jest (--json)
+//   gotest - вывод тестового бинарника Go (-test.v=test2json)
+
+// Форматы отчетов
+const (
+	FormatJUnit  = "junit"
+	FormatJest   = "jest"
+	FormatGoTest = "gotest"
+)
+
+// maxMessage - сколько символов сообщения об ошибке отдавать ученику
+const maxMessage = 2000
+
+// Parse разбирает отчет. Ошибка - отчет пустой или не в формате
+func Parse(format, report string) ([]models.TestResult, error) {
+	if strings.TrimSpace(report) == "" {
+		return nil, fmt.Errorf("empty test report")
+	}
+
+	var results []models.TestResult
+	var err error
+	switch format {
+	case FormatJUnit:
+		results, err = parseJUnit(report)
+	case FormatJest:
+		results, err = parseJest(report)
+	case FormatGoTest:
+		results, err = parseGoTest(report)
+	default:
+		return nil, fmt.Errorf("unknown report format: %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no tests found in report")
+	}
+	return results, nil
+}
+
+// Verdict - общий вердикт: первый упавший тест, пропущенные не считаются
+func Verdict(results []models.TestResult) string {
+	for _, result := range results {
+		if result.Verdict != models.VerdictOK && result.Verdict != models.VerdictSkipped {
+			return result.Verdict
+		}
+	}
+	return models.VerdictOK
+}
+
+// JUnit XML. Корень - testsuites или testsuite, наборы могут быть вложенными
+
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Result    string        `xml:"result,attr"` // GoogleTest: completed, skipped
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func parseJUnit(report string) ([]models.TestResult, error) {
+	var root junitSuite
+	if err := xml.Unmarshal([]byte(report), &root); err != nil {
+		return nil, fmt.Errorf("invalid JUnit report: %v", err)
+	}
+
+	var results []models.TestResult
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, c := range suite.Cases {
+			results = append(results, junitResult(c))
+		}
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	return results, nil
+}
+
+func junitResult(c junitCase) models.TestResult {
+	name := c.Name
+	if c.ClassName != "" {
+		name = c.ClassName + "." + c.Name
+	}
+	result := models.TestResult{Name: name, Verdict: models.VerdictOK}
+	if seconds, err := strconv.ParseFloat(c.Time, 64); err == nil {
+		result.TimeMs = int64(seconds * 1000)
+	}
+
+	switch {
+	case c.Failure != nil:
+		result.Verdict = models.VerdictWrongAnswer
+		result.Message = problemMessage(c.Failure)
+	case c.Error != nil:
+		result.Verdict = models.VerdictRuntimeError
+		result.Message = problemMessage(c.Error)
+	case c.Skipped != nil || c.Result == "skipped":
+		result.Verdict = models.VerdictSkipped
+		if c.Skipped != nil {
+			result.Message = problemMessage(c.Skipped)
+		}
+	}
+	return result
+}
+
+func problemMessage(p *junitProblem) string {
+	message := strings.TrimSpace(p.Message)
+	if text := strings.TrimSpace(p.Text); text != "" && !strings.Contains(message, text) {
+		if message != "" {
+			message += "\n"
+		}
+		message += text
+	}
+	return truncate(message)
+}
+
+// Jest --json
+
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Message          string `json:"message"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			Duration        *float64 `json:"duration"`
+			FailureMessages []string `json:"failureMessages"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+func parseJest(report string) ([]models.TestResult, error) {
+	var parsed jestReport
+	if err := json.Unmarshal([]byte(report), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid Jest report: %v", err)
+	}
+
+	var results []models.TestResult
+	for _, file := range parsed.TestResults {
+		// Файл тестов не загрузился (например, синтаксическая ошибка в решении)
+		if len(file.AssertionResults) == 0 && file.Status == "failed" {
+			results = append(results, models.TestResult{
+				Name:    path.Base(file.Name),
+				Verdict: models.VerdictRuntimeError,
+				Message: truncate(file.Message),
+			})
+			continue
+		}
+
+		for _, assertion := range file.AssertionResults {
+			result := models.TestResult{Name: assertion.FullName, Verdict: models.VerdictOK}
+			if assertion.Duration != nil {
+				result.TimeMs = int64(*assertion.Duration)
+			}
+			switch assertion.Status {
+			case "passed":
+			case "failed":
+				result.Verdict = models.VerdictWrongAnswer
+				result.Message = truncate(strings.Join(assertion.FailureMessages, "\n"))
+			default: // pending, skipped, todo, disabled
+				result.Verdict = models.VerdictSkipped
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// Вывод тестового бинарника Go с -test.v=test2json (то же, что разбирает
+// go tool test2json). Служебные строки начинаются с \x16, остальное -
+// вывод текущего теста. go tool в песочнице не запустить: ему нужен кэш сборки
+
+const (
+	goFrameMarker = "\x16"
+	// Вывод t.Error обрамляется \x0f ... \x0e
+	goErrorStart = "\x0f"
+	goErrorEnd   = "\x0e"
+)
+
+func parseGoTest(report string) ([]models.TestResult, error) {
+	var order []string
+	output := make(map[string]*strings.Builder)
+	results := make(map[string]models.TestResult)
+	var current, panicLine string
+
+	for _, line := range strings.Split(report, "\n") {
+		if !strings.HasPrefix(line, goFrameMarker) {
+			if current != "" {
+				line = strings.NewReplacer(goErrorStart, "", goErrorEnd, "").Replace(line)
+				output[current].WriteString(line + "\n")
+			} else if strings.HasPrefix(line, "panic: ") && panicLine == "" {
+				panicLine = line
+			}
+			continue
+		}
+
+		frame := strings.TrimPrefix(line, goFrameMarker)
+		switch {
+		case strings.HasPrefix(frame, "=== RUN"):
+			current = strings.TrimSpace(strings.TrimPrefix(frame, "=== RUN"))
+			if _, seen := output[current]; !seen {
+				order = append(order, current)
+				output[current] = &strings.Builder{}
+			}
+		case strings.HasPrefix(frame, "=== NAME"), strings.HasPrefix(frame, "=== CONT"):
+			current = strings.TrimSpace(frame[len("=== NAME"):])
+			if _, seen := output[current]; !seen {
+				current = ""
+			}
+		case strings.HasPrefix(frame, "--- "):
+			if result, ok := goTestResult(frame); ok {
+				results[result.Name] = result
+			}
+		}
+	}
+
+	var parsed []models.TestResult
+	for _, name := range order {
+		result, finished := results[name]
+		if !finished {
+			// Тест не завершился: бинарник упал или превысил лимит
+			result = models.TestResult{Name: name, Verdict: models.VerdictRuntimeError}
+		}
+		if result.Verdict != models.VerdictOK {
+			result.Message = truncate(output[name].String())
+			if result.Message == "" && result.Verdict != models.VerdictSkipped {
+				result.Message = panicLine
+			}
+			if result.Verdict == models.VerdictWrongAnswer && strings.HasPrefix(result.Message, "panic: ") {
+				result.Verdict = models.VerdictRuntimeError
+			}
+		}
+		parsed = append(parsed, result)
+	}
+	return parsed, nil
+}
+
+// goTestResult разбирает строку "--- FAIL: TestName (0.01s)"
+func goTestResult(frame string) (models.TestResult, bool) {
+	status, rest, ok := strings.Cut(strings.TrimPrefix(frame, "--- "), ": ")
+	if !ok {
+		return models.TestResult{}, false
+	}
+	verdict, known := map[string]string{
+		"PASS": models.VerdictOK,
+		"FAIL": models.VerdictWrongAnswer,
+		"SKIP": models.VerdictSkipped,
+	}[status]
+	if !known {
+		return models.TestResult{}, false
+	}
+
+	result := models.TestResult{Name: rest, Verdict: verdict}
+	if open := strings.LastIndex(rest, " ("); open >= 0 {
+		result.Name = rest[:open]
+		elapsed := strings.TrimSuffix(strings.TrimPrefix(rest[open:], " ("), "s)")
+		if seconds, err := strconv.ParseFloat(elapsed, 64); err == nil {
+			result.TimeMs = int64(seconds * 1000)
+		}
+	}
+	return result, true
+}
+
+func truncate(message string) string {
+	message = strings.TrimSpace(message)
+	if len(message) > maxMessage {
+		return strings.ToValidUTF8(message[:maxMessage], "") + "..."
+	}
+	return message
+}
@@ -4,26 +4,71 @@ import (
 	"archive/tar"
 	"bytes"
 	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+// TarFile файл архива. Name - относительный путь через /, с / на конце -
+// каталог. Mode 0 - 0644 для файла и 0755 для каталога. UID и GID -
+// владелец, им же принадлежат недостающие каталоги на пути к файлу
+type TarFile struct {
+	Name    string
+	Content string
+	Mode    int64
+	UID     int
+	GID     int
+}
+
 func CreateTarArchive(content, filename string) (io.Reader, error) {
+	return CreateTarArchiveFiles([]TarFile{{Name: filename, Content: content}})
+}
+
+// CreateTarArchiveFiles собирает архив из нескольких файлов, каталоги
+// по путям добавляются сами
+func CreateTarArchiveFiles(files []TarFile) (io.Reader, error) {
 	var buf bytes.Buffer
 	tw := tar.NewWriter(&buf)
+	now := time.Now()
 
-	header := &tar.Header{
-		Name:    filename,
-		Size:    int64(len(content)),
-		Mode:    0644,
-		ModTime: time.Now(),
-	}
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name, "/")
+		if err := writeTarDirs(tw, path.Dir(name), file, dirs, now); err != nil {
+			return nil, err
+		}
 
-	if err := tw.WriteHeader(header); err != nil {
-		return nil, err
-	}
+		if strings.HasSuffix(file.Name, "/") {
+			if !dirs[name] {
+				dirs[name] = true
+				if err := tw.WriteHeader(dirHeader(name, file, now)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
 
-	if _, err := tw.Write([]byte(content)); err != nil {
-		return nil, err
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		header := &tar.Header{
+			Name:    file.Name,
+			Size:    int64(len(file.Content)),
+			Mode:    mode,
+			Uid:     file.UID,
+			Gid:     file.GID,
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := tw.Close(); err != nil {
@@ -32,3 +77,63 @@ func CreateTarArchive(content, filename string) (io.Reader, error) {
 
 	return &buf, nil
 }
+
+// writeTarDirs добавляет каталог dir и его родителей, если их еще нет в архиве
+func writeTarDirs(tw *tar.Writer, dir string, owner TarFile, written map[string]bool, modTime time.Time) error {
+	if dir == "." || dir == "/" || written[dir] {
+		return nil
+	}
+	if err := writeTarDirs(tw, path.Dir(dir), owner, written, modTime); err != nil {
+		return err
+	}
+	written[dir] = true
+	return tw.WriteHeader(dirHeader(dir, TarFile{UID: owner.UID, GID: owner.GID}, modTime))
+}
+
+func dirHeader(dir string, file TarFile, modTime time.Time) *tar.Header {
+	mode := file.Mode
+	if mode == 0 {
+		mode = 0755
+	}
+	return &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dir + "/",
+		Mode:     mode,
+		Uid:      file.UID,
+		Gid:      file.GID,
+		ModTime:  modTime,
+	}
+}
+
+// CreateTarArchiveDir собирает архив из дерева каталога dir с сохранением
+// прав файлов (например, контекст сборки образа). Симлинки пропускаются
+func CreateTarArchiveDir(dir string) (io.Reader, error) {
+	var files []TarFile
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, TarFile{
+			Name:    filepath.ToSlash(rel),
+			Content: string(content),
+			Mode:    int64(info.Mode().Perm()),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CreateTarArchiveFiles(files)
+}